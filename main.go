@@ -1,8 +1,24 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/droplez/droplez-studio/migrations"
+	"github.com/droplez/droplez-studio/pkg/repo"
+	"github.com/droplez/droplez-studio/pkg/repo/memory"
+	"github.com/droplez/droplez-studio/pkg/repo/sqlite"
 	"github.com/droplez/droplez-studio/pkg/server"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/third_party/postgres"
+	sqliteClient "github.com/droplez/droplez-studio/third_party/sqlite"
+	"github.com/droplez/droplez-studio/third_party/storage"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/droplez/droplez-studio/tools/metrics"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -12,21 +28,216 @@ func init() {
 	// server variables
 	viper.SetDefault("droplez_studio_host", "0.0.0.0")
 	viper.SetDefault("droplez_studio_port", "9090")
+	viper.SetDefault("droplez_studio_http_port", "8080")
+	viper.SetDefault("cors_allowed_origins", "")
+	viper.SetDefault("shutdown_grace_period", "30s")
+	viper.SetDefault("health_check_interval", "10s")
+	viper.SetDefault("health_check_timeout", "5s")
+	// tls variables, the server runs in plaintext without a key pair
+	viper.SetDefault("tls_cert_file", "")
+	viper.SetDefault("tls_key_file", "")
+	viper.SetDefault("tls_client_ca_file", "")
+	viper.SetDefault("tls_client_auth", "none")
+	// the http listener only requires client certificates when set to require,
+	// it verifies them when they're given by default
+	viper.SetDefault("tls_http_client_auth", "")
+	// tracing variables, spans are exported to an otlp collector or written to a file
+	viper.SetDefault("tracing_exporter", "none")
+	viper.SetDefault("tracing_otlp_endpoint", "localhost:4317")
+	viper.SetDefault("tracing_otlp_insecure", false)
+	viper.SetDefault("tracing_file", "traces.json")
+	viper.SetDefault("tracing_sample_ratio", 1.0)
+	// download variables
+	viper.SetDefault("download_base_url", "http://localhost:8080")
+	viper.SetDefault("download_signing_key", "")
+	viper.SetDefault("download_url_ttl", "15m")
+	// idempotency variables, how long a retried creation gets the first response
+	viper.SetDefault("idempotency_window", "24h")
+	// how long a call holds its key, a retry takes over the key of a call that died
+	viper.SetDefault("idempotency_lease", "1m")
+	// list variables, cursors are signed so callers can't forge them
+	viper.SetDefault("list_cursor_signing_key", "")
+	viper.SetDefault("list_max_page_size", 1000)
+	// storage variables
+	viper.SetDefault("storage_path", "objects")
+	// sqlite variables, the database file of --storage=sqlite
+	viper.SetDefault("sqlite_path", "droplez-studio.db")
 	// database variables
 	viper.SetDefault("database_username", "droplez_studio")
 	viper.SetDefault("database_password", "qwertyu9")
 	viper.SetDefault("database_name", "droplez_studio")
 	viper.SetDefault("database_host", "localhost")
 	viper.SetDefault("database_port", "5432")
+	viper.SetDefault("database_max_conns", 10)
+	viper.SetDefault("database_min_conns", 0)
+	viper.SetDefault("database_max_conn_lifetime", "1h")
+	viper.SetDefault("database_max_conn_idle_time", "30m")
+	viper.SetDefault("database_health_check_period", "1m")
+	viper.SetDefault("database_connect_timeout", "5s")
+	viper.SetDefault("database_statement_timeout", "0s")
+	viper.SetDefault("database_connect_retries", 5)
+	viper.SetDefault("database_connect_retry_delay", "5s")
 	// read environment variables that match
 	viper.AutomaticEnv()
 }
 
+// Storage modes, set with --storage or the STORAGE variable
+const (
+	storagePostgres = "postgres"
+	storageSQLite   = "sqlite"
+	storageMemory   = "memory"
+)
+
 func main() {
-	if err := migrations.Migrate(); err != nil {
-		panic(err)
+	if err := run(); err != nil {
+		logger.GetServerLogger().Error(err)
+		os.Exit(1)
+	}
+}
+
+// run serves until a signal stops the server, its errors are returned so
+// the deferred cleanups run on the way out
+func run() error {
+	pflag.String("storage", storagePostgres, "where projects and versions are kept: postgres, sqlite for a single user, or memory for demos")
+	pflag.Parse()
+	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
+		return err
 	}
-	if err := server.Serve(); err != nil {
-		panic(err)
+
+	var stores *backends
+	switch mode := viper.GetString("storage"); mode {
+	case storagePostgres:
+		if err := migrations.Migrate(); err != nil {
+			return err
+		}
+		if err := postgres.Open(context.Background()); err != nil {
+			return err
+		}
+		defer postgres.Close()
+		stores = postgresBackends()
+	case storageSQLite:
+		// Opening creates the database file, so it's migrated afterwards
+		if err := sqliteClient.Open(context.Background()); err != nil {
+			return err
+		}
+		defer sqliteClient.Close()
+		if err := migrations.MigrateSQLite(); err != nil {
+			return err
+		}
+		stores = sqliteBackends()
+	case storageMemory:
+		stores = memoryBackends()
+	default:
+		return fmt.Errorf("unknown storage: %s", mode)
+	}
+
+	return server.Serve(newServices(stores))
+}
+
+// backends keep the data of the services
+type backends struct {
+	projects    service.ProjectStore
+	versions    service.VersionStore
+	shareLinks  service.ShareLinkStore
+	idempotency service.IdempotencyStore
+	search      service.SearchStore
+	tags        service.TagStore
+	collections service.CollectionStore
+	blobs       storage.Backend
+	checks      map[string]func(context.Context) error
+}
+
+func postgresBackends() *backends {
+	pool := postgres.Pool()
+	blobs := storage.Default()
+	metrics.Registry.MustRegister(postgres.Collector{}, storage.NewCollector(blobs))
+	return &backends{
+		projects:    repo.ProjectRepo{Pool: pool},
+		versions:    repo.VersionRepo{Pool: pool},
+		shareLinks:  repo.ShareLinkRepo{Pool: pool},
+		idempotency: repo.IdempotencyRepo{Pool: pool},
+		search:      repo.SearchRepo{Pool: pool},
+		tags:        repo.TagRepo{Pool: pool},
+		collections: repo.CollectionRepo{Pool: pool},
+		blobs:       blobs,
+		checks: map[string]func(context.Context) error{
+			"database":   postgres.Ping,
+			"migrations": func(ctx context.Context) error { return migrations.Check() },
+			"storage":    func(ctx context.Context) error { return storage.Probe(ctx, blobs) },
+		},
+	}
+}
+
+// sqliteBackends keep everything on the local disk, in a database file and
+// the objects directory
+func sqliteBackends() *backends {
+	db := sqliteClient.DB()
+	blobs := storage.Default()
+	metrics.Registry.MustRegister(collectors.NewDBStatsCollector(db, "sqlite"), storage.NewCollector(blobs))
+	return &backends{
+		projects:    sqlite.ProjectRepo{DB: db},
+		versions:    sqlite.VersionRepo{DB: db},
+		shareLinks:  sqlite.ShareLinkRepo{DB: db},
+		idempotency: sqlite.IdempotencyRepo{DB: db},
+		search:      sqlite.SearchRepo{DB: db},
+		tags:        sqlite.TagRepo{DB: db},
+		collections: sqlite.CollectionRepo{DB: db},
+		blobs:       blobs,
+		checks: map[string]func(context.Context) error{
+			"database":   sqliteClient.Ping,
+			"migrations": func(ctx context.Context) error { return migrations.CheckSQLite() },
+			"storage":    func(ctx context.Context) error { return storage.Probe(ctx, blobs) },
+		},
+	}
+}
+
+// memoryBackends keep everything in memory, nothing survives a restart
+func memoryBackends() *backends {
+	logger.GetServerLogger().Warn("storage is in memory, everything is lost on shutdown")
+	blobs := storage.Instrument(storage.NewMemory())
+	metrics.Registry.MustRegister(storage.NewCollector(blobs))
+	projects := memory.NewProjectRepo()
+	versions := memory.NewVersionRepo(projects)
+	return &backends{
+		projects:    projects,
+		versions:    versions,
+		shareLinks:  memory.NewShareLinkRepo(),
+		idempotency: memory.NewIdempotencyRepo(),
+		search:      memory.NewSearchRepo(projects, versions),
+		tags:        memory.NewTagRepo(projects),
+		collections: memory.NewCollectionRepo(projects),
+		blobs:       blobs,
+		checks: map[string]func(context.Context) error{
+			"storage": func(ctx context.Context) error { return storage.Probe(ctx, blobs) },
+		},
+	}
+}
+
+// newServices wires the service layer up with its stores and backends
+func newServices(backends *backends) *service.Services {
+	idempotency := service.NewIdempotency(backends.idempotency, viper.GetDuration("idempotency_window"), viper.GetDuration("idempotency_lease"), time.Now)
+	pager := service.NewPager(service.ListConfig{
+		CursorKey:   []byte(viper.GetString("list_cursor_signing_key")),
+		MaxPageSize: viper.GetInt("list_max_page_size"),
+	})
+	projects := service.NewProjectService(backends.projects, idempotency, pager, time.Now, service.NewUUID)
+	versions := service.NewVersionService(backends.versions, backends.projects, idempotency, pager, time.Now, service.NewUUID)
+	shareLinks := service.NewShareLinkService(backends.shareLinks, projects, versions, time.Now, service.NewUUID)
+	downloads := service.NewDownloadService(versions, projects, shareLinks, backends.blobs, time.Now, service.DownloadConfig{
+		BaseURL:    viper.GetString("download_base_url"),
+		TTL:        viper.GetDuration("download_url_ttl"),
+		SigningKey: []byte(viper.GetString("download_signing_key")),
+	})
+
+	return &service.Services{
+		Projects:    projects,
+		Versions:    versions,
+		ShareLinks:  shareLinks,
+		Downloads:   downloads,
+		Search:      service.NewSearchService(backends.search, pager),
+		Tags:        service.NewTagService(backends.tags, projects),
+		Collections: service.NewCollectionService(backends.collections, backends.projects, time.Now, service.NewUUID),
+		Blobs:       backends.blobs,
+		Checks:      backends.checks,
 	}
 }
@@ -1,26 +1,43 @@
 package migrations
 
 import (
+	"errors"
 	"fmt"
+	"os"
 
 	postgresClient "github.com/droplez/droplez-studio/third_party/postgres"
+	sqliteClient "github.com/droplez/droplez-studio/third_party/sqlite"
 	"github.com/droplez/droplez-studio/tools/logger"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 )
 
+// Postgres and SQLite have their own scripts, they create the same tables
+const (
+	scripts       = "file://migrations/scripts"
+	sqliteScripts = "file://migrations/sqlite"
+)
+
 func Migrate() error {
+	return up(newMigrate)
+}
+
+// MigrateSQLite migrates the database file of the sqlite storage
+func MigrateSQLite() error {
+	return up(newSQLiteMigrate)
+}
+
+func up(newMigrate func() (*migrate.Migrate, error)) error {
 	log := logger.GetServerLogger()
-	params := postgresClient.NewConnectionParams()
-	connectionString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", params.Username, params.Password, params.Host, params.Port, params.Database)
-	m, err := migrate.New(
-		"file://migrations/scripts",
-		connectionString)
+	m, err := newMigrate()
 	if err != nil {
 		log.Error(err)
 		return err
 	}
+	defer m.Close()
 	err = m.Up()
 	if err != nil {
 		if err == migrate.ErrNoChange {
@@ -32,3 +49,70 @@ func Migrate() error {
 	}
 	return nil
 }
+
+// Check returns an error unless the database is migrated to the latest script
+func Check() error {
+	return check(newMigrate, scripts)
+}
+
+// CheckSQLite is Check for the database file of the sqlite storage
+func CheckSQLite() error {
+	return check(newSQLiteMigrate, sqliteScripts)
+}
+
+func check(newMigrate func() (*migrate.Migrate, error), scripts string) error {
+	m, err := newMigrate()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("migration %d is dirty", version)
+	}
+	latest, err := latestVersion(scripts)
+	if err != nil {
+		return err
+	}
+	if version != latest {
+		return fmt.Errorf("database is at migration %d, the latest is %d", version, latest)
+	}
+	return nil
+}
+
+func newMigrate() (*migrate.Migrate, error) {
+	params := postgresClient.NewConnectionParams()
+	connectionString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", params.Username, params.Password, params.Host, params.Port, params.Database)
+	return migrate.New(scripts, connectionString)
+}
+
+func newSQLiteMigrate() (*migrate.Migrate, error) {
+	return migrate.New(sqliteScripts, "sqlite://"+sqliteClient.Path())
+}
+
+func latestVersion(scripts string) (uint, error) {
+	driver, err := source.Open(scripts)
+	if err != nil {
+		return 0, err
+	}
+	defer driver.Close()
+
+	version, err := driver.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := driver.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
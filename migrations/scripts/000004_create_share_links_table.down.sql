@@ -0,0 +1 @@
+DROP TABLE share_links;
\ No newline at end of file
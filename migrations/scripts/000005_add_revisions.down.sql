@@ -0,0 +1,2 @@
+ALTER TABLE projects DROP COLUMN revision;
+ALTER TABLE versions DROP COLUMN revision;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE projects ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE versions ADD COLUMN revision BIGINT NOT NULL DEFAULT 1;
\ No newline at end of file
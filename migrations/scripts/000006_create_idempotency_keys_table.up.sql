@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  request_hash TEXT NOT NULL,
+  response BYTEA,
+  revision BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL,
+  locked_until TIMESTAMP NOT NULL,
+  PRIMARY KEY (scope, key)
+);
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
\ No newline at end of file
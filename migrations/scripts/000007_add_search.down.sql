@@ -0,0 +1,2 @@
+ALTER TABLE versions DROP COLUMN search;
+ALTER TABLE projects DROP COLUMN search;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE projects ADD COLUMN search tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', name), 'A') ||
+  setweight(to_tsvector('english', genre), 'B') ||
+  setweight(to_tsvector('english', description), 'C')
+) STORED;
+ALTER TABLE versions ADD COLUMN search tsvector GENERATED ALWAYS AS (
+  setweight(to_tsvector('english', message), 'C')
+) STORED;
+CREATE INDEX projects_search_idx ON projects USING GIN (search);
+CREATE INDEX versions_search_idx ON versions USING GIN (search);
\ No newline at end of file
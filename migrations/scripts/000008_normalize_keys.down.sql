@@ -0,0 +1,4 @@
+-- The keys rewritten by the up migration keep their canonical names, their
+-- original spelling is lost, and the emptied ones stay empty
+DROP INDEX projects_key_bpm_idx;
+ALTER TABLE projects ALTER COLUMN key SET DEFAULT 'none';
\ No newline at end of file
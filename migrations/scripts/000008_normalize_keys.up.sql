@@ -0,0 +1,115 @@
+-- Rewrites the keys of projects to the names models.ParseKey gives them,
+-- like "A minor" or "a m" to "Am" and "8A" to "Am". The tables are the
+-- grammar of ParseKey: a note, its letter in either case, then an optional
+-- mode after optional whitespace, or a number and a letter of the Camelot or
+-- Open Key wheel, in either case. Keys ParseKey can't read, like the 'none'
+-- the column defaulted to, are emptied since projects without a key have an
+-- empty one. The original spelling isn't kept, the down migration can't undo
+-- this.
+WITH notes (note, major, minor) AS (VALUES
+  ('C', 'C', 'Cm'),
+  ('C#', 'Db', 'C#m'),
+  ('C♯', 'Db', 'C#m'),
+  ('Cb', 'B', 'Bm'),
+  ('C♭', 'B', 'Bm'),
+  ('D', 'D', 'Dm'),
+  ('D#', 'Eb', 'Ebm'),
+  ('D♯', 'Eb', 'Ebm'),
+  ('Db', 'Db', 'C#m'),
+  ('D♭', 'Db', 'C#m'),
+  ('E', 'E', 'Em'),
+  ('E#', 'F', 'Fm'),
+  ('E♯', 'F', 'Fm'),
+  ('Eb', 'Eb', 'Ebm'),
+  ('E♭', 'Eb', 'Ebm'),
+  ('F', 'F', 'Fm'),
+  ('F#', 'F#', 'F#m'),
+  ('F♯', 'F#', 'F#m'),
+  ('Fb', 'E', 'Em'),
+  ('F♭', 'E', 'Em'),
+  ('G', 'G', 'Gm'),
+  ('G#', 'Ab', 'G#m'),
+  ('G♯', 'Ab', 'G#m'),
+  ('Gb', 'F#', 'F#m'),
+  ('G♭', 'F#', 'F#m'),
+  ('A', 'A', 'Am'),
+  ('A#', 'Bb', 'Bbm'),
+  ('A♯', 'Bb', 'Bbm'),
+  ('Ab', 'Ab', 'G#m'),
+  ('A♭', 'Ab', 'G#m'),
+  ('B', 'B', 'Bm'),
+  ('B#', 'C', 'Cm'),
+  ('B♯', 'C', 'Cm'),
+  ('Bb', 'Bb', 'Bbm'),
+  ('B♭', 'Bb', 'Bbm')
+), modes (mode, minor) AS (VALUES
+  ('', false), ('m', true), ('min', true), ('minor', true), ('maj', false), ('major', false)
+), wheel (key, name) AS (VALUES
+  ('1a', 'G#m'),
+  ('1b', 'B'),
+  ('1m', 'Am'),
+  ('1d', 'C'),
+  ('2a', 'Ebm'),
+  ('2b', 'F#'),
+  ('2m', 'Em'),
+  ('2d', 'G'),
+  ('3a', 'Bbm'),
+  ('3b', 'Db'),
+  ('3m', 'Bm'),
+  ('3d', 'D'),
+  ('4a', 'Fm'),
+  ('4b', 'Ab'),
+  ('4m', 'F#m'),
+  ('4d', 'A'),
+  ('5a', 'Cm'),
+  ('5b', 'Eb'),
+  ('5m', 'C#m'),
+  ('5d', 'E'),
+  ('6a', 'Gm'),
+  ('6b', 'Bb'),
+  ('6m', 'G#m'),
+  ('6d', 'B'),
+  ('7a', 'Dm'),
+  ('7b', 'F'),
+  ('7m', 'Ebm'),
+  ('7d', 'F#'),
+  ('8a', 'Am'),
+  ('8b', 'C'),
+  ('8m', 'Bbm'),
+  ('8d', 'Db'),
+  ('9a', 'Em'),
+  ('9b', 'G'),
+  ('9m', 'Fm'),
+  ('9d', 'Ab'),
+  ('10a', 'Bm'),
+  ('10b', 'D'),
+  ('10m', 'Cm'),
+  ('10d', 'Eb'),
+  ('11a', 'F#m'),
+  ('11b', 'A'),
+  ('11m', 'Gm'),
+  ('11d', 'Bb'),
+  ('12a', 'C#m'),
+  ('12b', 'E'),
+  ('12m', 'Dm'),
+  ('12d', 'F')
+), spelled AS (
+  SELECT id, upper(substr(key, 1, 1)) || substr(key, 2) AS key, lower(key) AS lowered
+  FROM (SELECT id, btrim(key, E' \t\n\v\f\r') AS key FROM projects) AS trimmed
+), canonical (id, name) AS (
+  SELECT spelled.id, CASE WHEN modes.minor THEN notes.minor ELSE notes.major END
+  FROM spelled, notes, modes
+  WHERE length(spelled.key) >= length(notes.note) + length(modes.mode)
+    AND substr(spelled.key, 1, length(notes.note)) = notes.note
+    AND substr(spelled.key, length(spelled.key) - length(modes.mode) + 1) = modes.mode
+    AND btrim(substr(spelled.key, length(notes.note) + 1, greatest(length(spelled.key) - length(notes.note) - length(modes.mode), 0)), E' \t\n\f\r') = ''
+  UNION ALL
+  SELECT spelled.id, wheel.name FROM spelled JOIN wheel ON wheel.key = spelled.lowered
+), normalized (id, key) AS (
+  SELECT projects.id, coalesce(canonical.name, '') FROM projects LEFT JOIN canonical ON canonical.id = projects.id
+)
+UPDATE projects SET key = normalized.key, revision = revision + 1
+FROM normalized
+WHERE projects.id = normalized.id AND projects.key <> normalized.key;
+ALTER TABLE projects ALTER COLUMN key SET DEFAULT '';
+CREATE INDEX projects_key_bpm_idx ON projects (key, bpm);
\ No newline at end of file
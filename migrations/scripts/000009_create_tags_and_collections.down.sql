@@ -0,0 +1,3 @@
+DROP TABLE collection_projects;
+DROP TABLE collections;
+DROP TABLE project_tags;
\ No newline at end of file
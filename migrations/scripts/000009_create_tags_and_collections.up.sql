@@ -0,0 +1,19 @@
+CREATE TABLE project_tags (
+  project_id UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+  tag TEXT NOT NULL,
+  PRIMARY KEY (project_id, tag)
+);
+CREATE INDEX project_tags_tag_idx ON project_tags (tag);
+CREATE TABLE collections (
+  id UUID PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL
+);
+CREATE TABLE collection_projects (
+  collection_id UUID NOT NULL REFERENCES collections (id) ON DELETE CASCADE,
+  project_id UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (collection_id, project_id)
+);
+CREATE INDEX collection_projects_project_id_idx ON collection_projects (project_id);
\ No newline at end of file
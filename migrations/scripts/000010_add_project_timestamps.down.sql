@@ -0,0 +1,5 @@
+DROP TRIGGER versions_last_version_at ON versions;
+DROP FUNCTION projects_last_version_at();
+ALTER TABLE projects DROP COLUMN last_version_at;
+ALTER TABLE projects DROP COLUMN updated_at;
+ALTER TABLE projects DROP COLUMN created_at;
\ No newline at end of file
@@ -0,0 +1,38 @@
+ALTER TABLE projects ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE projects ADD COLUMN updated_at TIMESTAMP;
+ALTER TABLE projects ADD COLUMN last_version_at TIMESTAMP;
+WITH activity AS (
+  SELECT project_id, min(uploaded_at) AS first_version_at, max(uploaded_at) AS last_version_at
+  FROM versions GROUP BY project_id
+)
+UPDATE projects SET
+  created_at = activity.first_version_at,
+  updated_at = activity.last_version_at,
+  last_version_at = activity.last_version_at
+FROM activity
+WHERE projects.id = activity.project_id;
+UPDATE projects SET created_at = now() AT TIME ZONE 'UTC', updated_at = now() AT TIME ZONE 'UTC'
+WHERE created_at IS NULL;
+ALTER TABLE projects ALTER COLUMN created_at SET NOT NULL;
+ALTER TABLE projects ALTER COLUMN updated_at SET NOT NULL;
+CREATE INDEX projects_created_at_idx ON projects (created_at, id);
+CREATE INDEX projects_updated_at_idx ON projects (updated_at, id);
+CREATE INDEX projects_last_version_at_idx ON projects (last_version_at);
+CREATE FUNCTION projects_last_version_at() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE projects SET last_version_at = (
+      SELECT max(uploaded_at) FROM versions WHERE project_id = OLD.project_id
+    ) WHERE id = OLD.project_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE projects SET last_version_at = (
+      SELECT max(uploaded_at) FROM versions WHERE project_id = NEW.project_id
+    ) WHERE id = NEW.project_id;
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+CREATE TRIGGER versions_last_version_at
+AFTER INSERT OR UPDATE OF project_id, uploaded_at OR DELETE ON versions
+FOR EACH ROW EXECUTE FUNCTION projects_last_version_at();
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE projects;
\ No newline at end of file
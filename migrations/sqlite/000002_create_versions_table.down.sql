@@ -0,0 +1 @@
+DROP TABLE versions;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE versions (
+  id TEXT PRIMARY KEY,
+  version INTEGER NOT NULL,
+  project_id TEXT NOT NULL,
+  object_name TEXT NOT NULL,
+  message TEXT NOT NULL,
+  uploaded_at TIMESTAMP NOT NULL,
+  UNIQUE (project_id, version)
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE share_links (
+  id TEXT PRIMARY KEY,
+  token_hash TEXT NOT NULL UNIQUE,
+  project_id TEXT NOT NULL,
+  version_id TEXT,
+  password_hash TEXT NOT NULL DEFAULT '',
+  expires_at TIMESTAMP,
+  max_downloads INTEGER NOT NULL DEFAULT 0,
+  downloads INTEGER NOT NULL DEFAULT 0,
+  revoked BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,8 @@
+DROP TRIGGER versions_search_delete;
+DROP TRIGGER versions_search_update;
+DROP TRIGGER versions_search_insert;
+DROP TRIGGER projects_search_delete;
+DROP TRIGGER projects_search_update;
+DROP TRIGGER projects_search_insert;
+DROP TABLE versions_search;
+DROP TABLE projects_search;
\ No newline at end of file
@@ -0,0 +1,22 @@
+CREATE VIRTUAL TABLE projects_search USING fts5(id UNINDEXED, name, genre, description, tokenize = 'porter unicode61');
+CREATE VIRTUAL TABLE versions_search USING fts5(id UNINDEXED, project_id UNINDEXED, message, tokenize = 'porter unicode61');
+INSERT INTO projects_search (id, name, genre, description) SELECT id, name, genre, description FROM projects;
+INSERT INTO versions_search (id, project_id, message) SELECT id, project_id, message FROM versions;
+CREATE TRIGGER projects_search_insert AFTER INSERT ON projects BEGIN
+  INSERT INTO projects_search (id, name, genre, description) VALUES (new.id, new.name, new.genre, new.description);
+END;
+CREATE TRIGGER projects_search_update AFTER UPDATE OF name, genre, description ON projects BEGIN
+  UPDATE projects_search SET name = new.name, genre = new.genre, description = new.description WHERE id = old.id;
+END;
+CREATE TRIGGER projects_search_delete AFTER DELETE ON projects BEGIN
+  DELETE FROM projects_search WHERE id = old.id;
+END;
+CREATE TRIGGER versions_search_insert AFTER INSERT ON versions BEGIN
+  INSERT INTO versions_search (id, project_id, message) VALUES (new.id, new.project_id, new.message);
+END;
+CREATE TRIGGER versions_search_update AFTER UPDATE OF project_id, message ON versions BEGIN
+  UPDATE versions_search SET project_id = new.project_id, message = new.message WHERE id = old.id;
+END;
+CREATE TRIGGER versions_search_delete AFTER DELETE ON versions BEGIN
+  DELETE FROM versions_search WHERE id = old.id;
+END;
\ No newline at end of file
@@ -0,0 +1,29 @@
+-- The keys rewritten by the up migration keep their canonical names, their
+-- original spelling is lost, and the emptied ones stay empty. The table is
+-- rebuilt with the 'none' default it had.
+DROP INDEX projects_key_bpm_idx;
+CREATE TABLE projects_rebuilt (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  daw TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  public BOOLEAN NOT NULL DEFAULT false,
+  bpm INTEGER DEFAULT 0,
+  key TEXT NOT NULL DEFAULT 'none',
+  genre TEXT NOT NULL DEFAULT 'none',
+  template BOOLEAN NOT NULL DEFAULT false,
+  revision INTEGER NOT NULL DEFAULT 1
+);
+INSERT INTO projects_rebuilt (id, name, daw, description, public, bpm, key, genre, template, revision)
+SELECT id, name, daw, description, public, bpm, key, genre, template, revision FROM projects;
+DROP TABLE projects;
+ALTER TABLE projects_rebuilt RENAME TO projects;
+CREATE TRIGGER projects_search_insert AFTER INSERT ON projects BEGIN
+  INSERT INTO projects_search (id, name, genre, description) VALUES (new.id, new.name, new.genre, new.description);
+END;
+CREATE TRIGGER projects_search_update AFTER UPDATE OF name, genre, description ON projects BEGIN
+  UPDATE projects_search SET name = new.name, genre = new.genre, description = new.description WHERE id = old.id;
+END;
+CREATE TRIGGER projects_search_delete AFTER DELETE ON projects BEGIN
+  DELETE FROM projects_search WHERE id = old.id;
+END;
\ No newline at end of file
@@ -0,0 +1,141 @@
+-- Rewrites the keys of projects to the names models.ParseKey gives them,
+-- like "A minor" or "a m" to "Am" and "8A" to "Am". The tables are the
+-- grammar of ParseKey: a note, its letter in either case, then an optional
+-- mode after optional whitespace, or a number and a letter of the Camelot or
+-- Open Key wheel, in either case. Keys ParseKey can't read, like the 'none'
+-- the column defaulted to, are emptied since projects without a key have an
+-- empty one. The original spelling isn't kept, the down migration can't undo
+-- this.
+-- SQLite can't change the default of a column, the table is rebuilt with an
+-- empty one and its search triggers are made again.
+CREATE TABLE projects_rebuilt (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  daw TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  public BOOLEAN NOT NULL DEFAULT false,
+  bpm INTEGER DEFAULT 0,
+  key TEXT NOT NULL DEFAULT '',
+  genre TEXT NOT NULL DEFAULT 'none',
+  template BOOLEAN NOT NULL DEFAULT false,
+  revision INTEGER NOT NULL DEFAULT 1
+);
+INSERT INTO projects_rebuilt (id, name, daw, description, public, bpm, key, genre, template, revision)
+SELECT id, name, daw, description, public, bpm, key, genre, template, revision FROM projects;
+DROP TABLE projects;
+ALTER TABLE projects_rebuilt RENAME TO projects;
+CREATE TRIGGER projects_search_insert AFTER INSERT ON projects BEGIN
+  INSERT INTO projects_search (id, name, genre, description) VALUES (new.id, new.name, new.genre, new.description);
+END;
+CREATE TRIGGER projects_search_update AFTER UPDATE OF name, genre, description ON projects BEGIN
+  UPDATE projects_search SET name = new.name, genre = new.genre, description = new.description WHERE id = old.id;
+END;
+CREATE TRIGGER projects_search_delete AFTER DELETE ON projects BEGIN
+  DELETE FROM projects_search WHERE id = old.id;
+END;
+WITH notes (note, major, minor) AS (VALUES
+  ('C', 'C', 'Cm'),
+  ('C#', 'Db', 'C#m'),
+  ('C♯', 'Db', 'C#m'),
+  ('Cb', 'B', 'Bm'),
+  ('C♭', 'B', 'Bm'),
+  ('D', 'D', 'Dm'),
+  ('D#', 'Eb', 'Ebm'),
+  ('D♯', 'Eb', 'Ebm'),
+  ('Db', 'Db', 'C#m'),
+  ('D♭', 'Db', 'C#m'),
+  ('E', 'E', 'Em'),
+  ('E#', 'F', 'Fm'),
+  ('E♯', 'F', 'Fm'),
+  ('Eb', 'Eb', 'Ebm'),
+  ('E♭', 'Eb', 'Ebm'),
+  ('F', 'F', 'Fm'),
+  ('F#', 'F#', 'F#m'),
+  ('F♯', 'F#', 'F#m'),
+  ('Fb', 'E', 'Em'),
+  ('F♭', 'E', 'Em'),
+  ('G', 'G', 'Gm'),
+  ('G#', 'Ab', 'G#m'),
+  ('G♯', 'Ab', 'G#m'),
+  ('Gb', 'F#', 'F#m'),
+  ('G♭', 'F#', 'F#m'),
+  ('A', 'A', 'Am'),
+  ('A#', 'Bb', 'Bbm'),
+  ('A♯', 'Bb', 'Bbm'),
+  ('Ab', 'Ab', 'G#m'),
+  ('A♭', 'Ab', 'G#m'),
+  ('B', 'B', 'Bm'),
+  ('B#', 'C', 'Cm'),
+  ('B♯', 'C', 'Cm'),
+  ('Bb', 'Bb', 'Bbm'),
+  ('B♭', 'Bb', 'Bbm')
+), modes (mode, minor) AS (VALUES
+  ('', false), ('m', true), ('min', true), ('minor', true), ('maj', false), ('major', false)
+), wheel (key, name) AS (VALUES
+  ('1a', 'G#m'),
+  ('1b', 'B'),
+  ('1m', 'Am'),
+  ('1d', 'C'),
+  ('2a', 'Ebm'),
+  ('2b', 'F#'),
+  ('2m', 'Em'),
+  ('2d', 'G'),
+  ('3a', 'Bbm'),
+  ('3b', 'Db'),
+  ('3m', 'Bm'),
+  ('3d', 'D'),
+  ('4a', 'Fm'),
+  ('4b', 'Ab'),
+  ('4m', 'F#m'),
+  ('4d', 'A'),
+  ('5a', 'Cm'),
+  ('5b', 'Eb'),
+  ('5m', 'C#m'),
+  ('5d', 'E'),
+  ('6a', 'Gm'),
+  ('6b', 'Bb'),
+  ('6m', 'G#m'),
+  ('6d', 'B'),
+  ('7a', 'Dm'),
+  ('7b', 'F'),
+  ('7m', 'Ebm'),
+  ('7d', 'F#'),
+  ('8a', 'Am'),
+  ('8b', 'C'),
+  ('8m', 'Bbm'),
+  ('8d', 'Db'),
+  ('9a', 'Em'),
+  ('9b', 'G'),
+  ('9m', 'Fm'),
+  ('9d', 'Ab'),
+  ('10a', 'Bm'),
+  ('10b', 'D'),
+  ('10m', 'Cm'),
+  ('10d', 'Eb'),
+  ('11a', 'F#m'),
+  ('11b', 'A'),
+  ('11m', 'Gm'),
+  ('11d', 'Bb'),
+  ('12a', 'C#m'),
+  ('12b', 'E'),
+  ('12m', 'Dm'),
+  ('12d', 'F')
+), spelled AS (
+  SELECT id, upper(substr(key, 1, 1)) || substr(key, 2) AS key, lower(key) AS lowered
+  FROM (SELECT id, trim(key, ' ' || char(9, 10, 11, 12, 13)) AS key FROM projects) AS trimmed
+), canonical (id, name) AS (
+  SELECT spelled.id, CASE WHEN modes.minor THEN notes.minor ELSE notes.major END
+  FROM spelled, notes, modes
+  WHERE length(spelled.key) >= length(notes.note) + length(modes.mode)
+    AND substr(spelled.key, 1, length(notes.note)) = notes.note
+    AND substr(spelled.key, length(spelled.key) - length(modes.mode) + 1) = modes.mode
+    AND trim(substr(spelled.key, length(notes.note) + 1, max(length(spelled.key) - length(notes.note) - length(modes.mode), 0)), ' ' || char(9, 10, 12, 13)) = ''
+  UNION ALL
+  SELECT spelled.id, wheel.name FROM spelled JOIN wheel ON wheel.key = spelled.lowered
+), normalized (id, key) AS (
+  SELECT projects.id, coalesce(canonical.name, '') FROM projects LEFT JOIN canonical ON canonical.id = projects.id
+)
+UPDATE projects SET key = normalized.key, revision = revision + 1
+FROM normalized
+WHERE projects.id = normalized.id AND projects.key <> normalized.key;
+CREATE INDEX projects_key_bpm_idx ON projects (key, bpm);
\ No newline at end of file
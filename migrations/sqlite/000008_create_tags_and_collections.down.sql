@@ -0,0 +1,5 @@
+DROP TRIGGER collections_delete;
+DROP TRIGGER projects_organize_delete;
+DROP TABLE collection_projects;
+DROP TABLE collections;
+DROP TABLE project_tags;
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE project_tags (
+  project_id TEXT NOT NULL,
+  tag TEXT NOT NULL,
+  PRIMARY KEY (project_id, tag)
+);
+CREATE INDEX project_tags_tag_idx ON project_tags (tag);
+CREATE TABLE collections (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL
+);
+CREATE TABLE collection_projects (
+  collection_id TEXT NOT NULL,
+  project_id TEXT NOT NULL,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (collection_id, project_id)
+);
+CREATE INDEX collection_projects_project_id_idx ON collection_projects (project_id);
+CREATE TRIGGER projects_organize_delete AFTER DELETE ON projects BEGIN
+  DELETE FROM project_tags WHERE project_id = old.id;
+  DELETE FROM collection_projects WHERE project_id = old.id;
+END;
+CREATE TRIGGER collections_delete AFTER DELETE ON collections BEGIN
+  DELETE FROM collection_projects WHERE collection_id = old.id;
+END;
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP TRIGGER versions_delete_last_version_at;
+DROP TRIGGER versions_update_last_version_at;
+DROP TRIGGER versions_insert_last_version_at;
+DROP INDEX projects_last_version_at_idx;
+DROP INDEX projects_updated_at_idx;
+DROP INDEX projects_created_at_idx;
+ALTER TABLE projects DROP COLUMN last_version_at;
+ALTER TABLE projects DROP COLUMN updated_at;
+ALTER TABLE projects DROP COLUMN created_at;
\ No newline at end of file
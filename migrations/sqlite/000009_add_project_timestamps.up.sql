@@ -0,0 +1,35 @@
+ALTER TABLE projects ADD COLUMN created_at TIMESTAMP;
+ALTER TABLE projects ADD COLUMN updated_at TIMESTAMP;
+ALTER TABLE projects ADD COLUMN last_version_at TIMESTAMP;
+WITH activity AS (
+  SELECT project_id, min(uploaded_at) AS first_version_at, max(uploaded_at) AS last_version_at
+  FROM versions GROUP BY project_id
+)
+UPDATE projects SET
+  created_at = activity.first_version_at,
+  updated_at = activity.last_version_at,
+  last_version_at = activity.last_version_at
+FROM activity
+WHERE projects.id = activity.project_id;
+UPDATE projects SET
+  created_at = strftime('%Y-%m-%d %H:%M:%f +0000 UTC', 'now'),
+  updated_at = strftime('%Y-%m-%d %H:%M:%f +0000 UTC', 'now')
+WHERE created_at IS NULL;
+CREATE INDEX projects_created_at_idx ON projects (created_at, id);
+CREATE INDEX projects_updated_at_idx ON projects (updated_at, id);
+CREATE INDEX projects_last_version_at_idx ON projects (last_version_at);
+CREATE TRIGGER versions_insert_last_version_at AFTER INSERT ON versions BEGIN
+  UPDATE projects SET last_version_at = (
+    SELECT max(uploaded_at) FROM versions WHERE project_id = new.project_id
+  ) WHERE id = new.project_id;
+END;
+CREATE TRIGGER versions_update_last_version_at AFTER UPDATE OF project_id, uploaded_at ON versions BEGIN
+  UPDATE projects SET last_version_at = (
+    SELECT max(uploaded_at) FROM versions WHERE project_id = projects.id
+  ) WHERE id IN (old.project_id, new.project_id);
+END;
+CREATE TRIGGER versions_delete_last_version_at AFTER DELETE ON versions BEGIN
+  UPDATE projects SET last_version_at = (
+    SELECT max(uploaded_at) FROM versions WHERE project_id = old.project_id
+  ) WHERE id = old.project_id;
+END;
\ No newline at end of file
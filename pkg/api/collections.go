@@ -0,0 +1,75 @@
+package api
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type collectionsGrpcImpl struct {
+	service *service.CollectionService
+}
+
+func RegisterCollectionsServer(grpcServer *grpc.Server, collectionService *service.CollectionService) {
+	grpcServer.RegisterService(&collectionsServiceDesc, &collectionsGrpcImpl{service: collectionService})
+}
+
+func (s collectionsGrpcImpl) Create(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	collection := &models.Collection{}
+	if err := fromStruct(in, collection); err != nil {
+		return nil, err
+	}
+	out, err := s.service.Create(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+// Update replaces a collection, its projects are sorted in the given order
+func (s collectionsGrpcImpl) Update(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	collection := &models.Collection{}
+	if err := fromStruct(in, collection); err != nil {
+		return nil, err
+	}
+	out, err := s.service.Update(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+func (s collectionsGrpcImpl) Get(ctx context.Context, in *wrapperspb.StringValue) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	out, err := s.service.Get(ctx, in.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+func (s collectionsGrpcImpl) List(ctx context.Context, _ *emptypb.Empty) (*structpb.ListValue, error) {
+	logger.EndpointHit(ctx)
+	out, err := s.service.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toList(out)
+}
+
+// Delete a collection, its projects are kept
+func (s collectionsGrpcImpl) Delete(ctx context.Context, in *wrapperspb.StringValue) (*emptypb.Empty, error) {
+	logger.EndpointHit(ctx)
+	if err := s.service.Delete(ctx, in.GetValue()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The Collections service only uses well-known types, like the Downloads
+// one. A collection is a struct with the JSON fields of the REST resource,
+// Get and Delete take its id.
+//
+//	service Collections {
+//	  rpc Create(google.protobuf.Struct) returns (google.protobuf.Struct);
+//	  rpc Update(google.protobuf.Struct) returns (google.protobuf.Struct);
+//	  rpc Get(google.protobuf.StringValue) returns (google.protobuf.Struct);
+//	  rpc List(google.protobuf.Empty) returns (google.protobuf.ListValue);
+//	  rpc Delete(google.protobuf.StringValue) returns (google.protobuf.Empty);
+//	}
+const collectionsProtoFile = "droplez/studio/collections.proto"
+
+func init() {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(collectionsProtoFile),
+		Package:    proto.String("droplez.studio.collections"),
+		Dependency: []string{"google/protobuf/empty.proto", "google/protobuf/wrappers.proto", "google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Collections"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Create"),
+					InputType:  proto.String(".google.protobuf.Struct"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("Update"),
+					InputType:  proto.String(".google.protobuf.Struct"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("Get"),
+					InputType:  proto.String(".google.protobuf.StringValue"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("List"),
+					InputType:  proto.String(".google.protobuf.Empty"),
+					OutputType: proto.String(".google.protobuf.ListValue"),
+				},
+				{
+					Name:       proto.String("Delete"),
+					InputType:  proto.String(".google.protobuf.StringValue"),
+					OutputType: proto.String(".google.protobuf.Empty"),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(file); err != nil {
+		panic(err)
+	}
+}
+
+type collectionsServer interface {
+	Create(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Update(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Get(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+	List(context.Context, *emptypb.Empty) (*structpb.ListValue, error)
+	Delete(context.Context, *wrapperspb.StringValue) (*emptypb.Empty, error)
+}
+
+var collectionsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "droplez.studio.collections.Collections",
+	HandlerType: (*collectionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    collectionsCreateHandler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    collectionsUpdateHandler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    collectionsGetHandler,
+		},
+		{
+			MethodName: "List",
+			Handler:    collectionsListHandler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    collectionsDeleteHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: collectionsProtoFile,
+}
+
+func collectionsCreateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(collectionsServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.collections.Collections/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(collectionsServer).Create(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func collectionsUpdateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(collectionsServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.collections.Collections/Update",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(collectionsServer).Update(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func collectionsGetHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(collectionsServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.collections.Collections/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(collectionsServer).Get(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func collectionsListHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(collectionsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.collections.Collections/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(collectionsServer).List(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func collectionsDeleteHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(collectionsServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.collections.Collections/Delete",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(collectionsServer).Delete(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
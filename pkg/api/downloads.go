@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/third_party/storage"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/droplez/droplez-studio/tools/tracing"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type downloadsGrpcImpl struct {
+	service *service.DownloadService
+}
+
+func RegisterDownloadsServer(grpcServer *grpc.Server, downloadService *service.DownloadService) {
+	grpcServer.RegisterService(&downloadsServiceDesc, &downloadsGrpcImpl{service: downloadService})
+}
+
+// CreateURL mints a signed URL to download the object of a version over HTTP,
+// it takes the version id and returns the URL
+func (s downloadsGrpcImpl) CreateURL(ctx context.Context, in *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	logger.EndpointHit(ctx)
+	url, err := s.service.CreateURL(ctx, &versions.VersionId{Id: in.GetValue()})
+	if err != nil {
+		return nil, err
+	}
+	return wrapperspb.String(url), nil
+}
+
+// DownloadsHandler serves version objects through signed URLs
+func DownloadsHandler(downloadService *service.DownloadService) http.Handler {
+	return http.StripPrefix(service.DownloadPath, downloadsHandler{service: downloadService})
+}
+
+type downloadsHandler struct {
+	service *service.DownloadService
+}
+
+func (h downloadsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Downloads continue the trace of the client that requested them
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracing.Start(ctx, "Downloads/Serve", semconv.HTTPMethodKey.String(r.Method))
+	defer span.End()
+
+	object, filename, err := h.service.Open(ctx, r.URL.Path, r.URL.Query())
+	if err != nil {
+		writeDownloadError(w, span, err)
+		return
+	}
+	defer object.Close()
+
+	if sendsFirstByte(r, object) {
+		if err := h.service.Count(ctx, r.URL.Query()); err != nil {
+			writeDownloadError(w, span, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	http.ServeContent(w, r, filename, object.ModTime(), object)
+}
+
+func writeDownloadError(w http.ResponseWriter, span trace.Span, err error) {
+	// The service already logged internal errors
+	st := status.Convert(err)
+	span.SetStatus(otelcodes.Error, st.Message())
+	http.Error(w, st.Message(), httpStatusFromCode(st.Code()))
+}
+
+// sendsFirstByte reports whether http.ServeContent sends the first byte of
+// the object for the request. Every complete download does it once, so it's
+// counted once, while the ranges resuming it are not. HEAD responses have
+// no body.
+func sendsFirstByte(r *http.Request, object storage.Object) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	rangeHeader := r.Header.Get("Range")
+	if rangeHeader == "" || !ifRangeMatches(r, object) {
+		return true
+	}
+	specs := strings.TrimPrefix(rangeHeader, "bytes=")
+	if specs == rangeHeader {
+		return true
+	}
+
+	// Ranges that can't be parsed are counted, ServeContent rejects them anyway
+	size := object.Size()
+	var total int64
+	for _, spec := range strings.Split(specs, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		i := strings.Index(spec, "-")
+		if i < 0 {
+			return true
+		}
+		first, last := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		var start, length int64
+		if first == "" {
+			// The last bytes of the object
+			n, err := strconv.ParseInt(last, 10, 64)
+			if err != nil || n < 0 {
+				return true
+			}
+			if n > size {
+				n = size
+			}
+			start, length = size-n, n
+		} else {
+			var err error
+			start, err = strconv.ParseInt(first, 10, 64)
+			if err != nil || start < 0 {
+				return true
+			}
+			if start >= size {
+				// ServeContent skips ranges past the end
+				continue
+			}
+			end := size - 1
+			if last != "" {
+				n, err := strconv.ParseInt(last, 10, 64)
+				if err != nil || n < start {
+					return true
+				}
+				if n < end {
+					end = n
+				}
+			}
+			length = end - start + 1
+		}
+		if start == 0 {
+			return true
+		}
+		total += length
+	}
+	// ServeContent sends the whole object when the ranges add up to more than it
+	return total > size
+}
+
+// ifRangeMatches mirrors the If-Range check of ServeContent, which ignores
+// the ranges when the validator doesn't match. No ETag is set, so only the
+// modification time can match.
+func ifRangeMatches(r *http.Request, object storage.Object) bool {
+	ifRange := r.Header.Get("If-Range")
+	if ifRange == "" {
+		return true
+	}
+	t, err := http.ParseTime(ifRange)
+	return err == nil && !object.ModTime().IsZero() && t.Unix() == object.ModTime().Unix()
+}
+
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Canceled:
+		return 499
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The Downloads service only uses well-known types, so it's described here
+// instead of droplez-go-proto. The descriptor is registered to make the
+// service visible through reflection.
+//
+//	service Downloads {
+//	  rpc CreateURL(google.protobuf.StringValue) returns (google.protobuf.StringValue);
+//	}
+const downloadsProtoFile = "droplez/studio/downloads.proto"
+
+func init() {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(downloadsProtoFile),
+		Package:    proto.String("droplez.studio.downloads"),
+		Dependency: []string{"google/protobuf/wrappers.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Downloads"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("CreateURL"),
+				InputType:  proto.String(".google.protobuf.StringValue"),
+				OutputType: proto.String(".google.protobuf.StringValue"),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(file); err != nil {
+		panic(err)
+	}
+}
+
+type downloadsServer interface {
+	CreateURL(context.Context, *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+var downloadsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "droplez.studio.downloads.Downloads",
+	HandlerType: (*downloadsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateURL",
+			Handler:    downloadsCreateURLHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: downloadsProtoFile,
+}
+
+func downloadsCreateURLHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(downloadsServer).CreateURL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.downloads.Downloads/CreateURL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(downloadsServer).CreateURL(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
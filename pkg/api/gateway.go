@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// GatewayPath prefixes every route of the REST gateway
+const GatewayPath = "/v1/"
+
+// gateway serves the grpc services as REST resources. Calls go through the
+// same interceptors as grpc ones, with http headers as incoming metadata.
+type gateway struct {
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+
+	// full names of the registered services, to report the same methods as grpc
+	projectsService    string
+	versionsService    string
+	downloadsService   string
+	searchService      string
+	shareLinksService  string
+	tagsService        string
+	collectionsService string
+
+	projects    projectsGrpcImpl
+	versions    versionsGrpcImpl
+	downloads   downloadsGrpcImpl
+	search      searchGrpcImpl
+	shareLinks  shareLinksGrpcImpl
+	tags        tagsGrpcImpl
+	collections collectionsGrpcImpl
+}
+
+// NewGateway returns the REST gateway of the services registered on grpcServer
+func NewGateway(grpcServer *grpc.Server, services *service.Services, unary grpc.UnaryServerInterceptor, stream grpc.StreamServerInterceptor) http.Handler {
+	g := &gateway{
+		unary:              unary,
+		stream:             stream,
+		projectsService:    ServiceName(grpcServer, "Projects"),
+		versionsService:    ServiceName(grpcServer, "Versions"),
+		downloadsService:   downloadsServiceDesc.ServiceName,
+		searchService:      searchServiceDesc.ServiceName,
+		shareLinksService:  shareLinksServiceDesc.ServiceName,
+		tagsService:        tagsServiceDesc.ServiceName,
+		collectionsService: collectionsServiceDesc.ServiceName,
+		projects:           projectsGrpcImpl{service: services.Projects},
+		versions:           versionsGrpcImpl{service: services.Versions},
+		downloads:          downloadsGrpcImpl{service: services.Downloads},
+		search:             searchGrpcImpl{service: services.Search},
+		shareLinks:         shareLinksGrpcImpl{service: services.ShareLinks},
+		tags:               tagsGrpcImpl{service: services.Tags},
+		collections:        collectionsGrpcImpl{service: services.Collections},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(GatewayPath+"projects", g.projectsCollection)
+	mux.HandleFunc(GatewayPath+"projects/", g.projectsItem)
+	mux.HandleFunc(GatewayPath+"versions", g.versionsCollection)
+	mux.HandleFunc(GatewayPath+"versions/", g.versionsItem)
+	mux.HandleFunc(GatewayPath+"search", g.searchCollection)
+	mux.HandleFunc(GatewayPath+"share-links", g.shareLinksCollection)
+	mux.HandleFunc(GatewayPath+"share-links/", g.shareLinksItem)
+	mux.HandleFunc(GatewayPath+"tags", g.tagsCollection)
+	mux.HandleFunc(GatewayPath+"collections", g.collectionsCollection)
+	mux.HandleFunc(GatewayPath+"collections/", g.collectionsItem)
+	return mux
+}
+
+// ServiceName finds the full name of a registered grpc service
+func ServiceName(grpcServer *grpc.Server, name string) string {
+	for fullName := range grpcServer.GetServiceInfo() {
+		if fullName == name || strings.HasSuffix(fullName, "."+name) {
+			return fullName
+		}
+	}
+	return name
+}
+
+// GET /v1/projects, POST /v1/projects
+func (g *gateway) projectsCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		in := &projects.ListOptions{}
+		if !decodeQuery(w, r, in) {
+			return
+		}
+		g.serverStreamCall(w, r, g.projectsService, "List", func(stream grpc.ServerStream) error {
+			return g.projects.List(in, &projectsListServer{stream})
+		})
+	case http.MethodPost:
+		in := &projects.ProjectMeta{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		g.unaryCall(w, r, g.projectsService, "Create", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.projects.Create(ctx, req.(*projects.ProjectMeta))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// GET, PUT and DELETE /v1/projects/{id}, GET and PUT /v1/projects/{id}/tags
+func (g *gateway) projectsItem(w http.ResponseWriter, r *http.Request) {
+	if id := strings.TrimPrefix(r.URL.Path, GatewayPath+"projects/"); strings.HasSuffix(id, "/tags") {
+		g.projectTags(w, r, strings.TrimSuffix(id, "/tags"))
+		return
+	}
+	id, ok := itemID(w, r, GatewayPath+"projects/")
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		in := &projects.ProjectId{Id: id}
+		g.unaryCall(w, r, g.projectsService, "Get", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.projects.Get(ctx, req.(*projects.ProjectId))
+		})
+	case http.MethodPut:
+		in := &projects.ProjectInfo{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		in.Id = &projects.ProjectId{Id: id}
+		g.unaryCall(w, r, g.projectsService, "Update", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.projects.Update(ctx, req.(*projects.ProjectInfo))
+		})
+	case http.MethodDelete:
+		// The project name is required to delete it, like with grpc
+		in := &projects.ProjectInfo{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		in.Id = &projects.ProjectId{Id: id}
+		g.unaryCall(w, r, g.projectsService, "Delete", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.projects.Delete(ctx, req.(*projects.ProjectInfo))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (g *gateway) projectTags(w http.ResponseWriter, r *http.Request, id string) {
+	switch r.Method {
+	case http.MethodGet:
+		in := wrapperspb.String(id)
+		g.unaryCall(w, r, g.tagsService, "Get", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.tags.Get(ctx, req.(*wrapperspb.StringValue))
+		})
+	case http.MethodPut:
+		in := &structpb.Struct{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		setStructField(in, "project_id", id)
+		g.unaryCall(w, r, g.tagsService, "Set", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.tags.Set(ctx, req.(*structpb.Struct))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+// GET /v1/versions, POST /v1/versions
+func (g *gateway) versionsCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		in := &versions.ListOptions{}
+		if !decodeQuery(w, r, in) {
+			return
+		}
+		g.serverStreamCall(w, r, g.versionsService, "List", func(stream grpc.ServerStream) error {
+			return g.versions.List(in, &versionsListServer{stream})
+		})
+	case http.MethodPost:
+		in := &versions.VersionMeta{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		g.unaryCall(w, r, g.versionsService, "Create", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.versions.Create(ctx, req.(*versions.VersionMeta))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// GET, PUT and DELETE /v1/versions/{id}, POST /v1/versions/{id}/download-url
+func (g *gateway) versionsItem(w http.ResponseWriter, r *http.Request) {
+	if id := strings.TrimPrefix(r.URL.Path, GatewayPath+"versions/"); strings.HasSuffix(id, "/download-url") {
+		g.versionDownloadURL(w, r, strings.TrimSuffix(id, "/download-url"))
+		return
+	}
+	id, ok := itemID(w, r, GatewayPath+"versions/")
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		in := &versions.VersionId{Id: id}
+		g.unaryCall(w, r, g.versionsService, "Get", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.versions.Get(ctx, req.(*versions.VersionId))
+		})
+	case http.MethodPut:
+		in := &versions.VersionInfo{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		in.Id = &versions.VersionId{Id: id}
+		g.unaryCall(w, r, g.versionsService, "Update", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.versions.Update(ctx, req.(*versions.VersionInfo))
+		})
+	case http.MethodDelete:
+		in := &versions.VersionInfo{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		in.Id = &versions.VersionId{Id: id}
+		g.unaryCall(w, r, g.versionsService, "Delete", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.versions.Delete(ctx, req.(*versions.VersionInfo))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+func (g *gateway) versionDownloadURL(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	in := wrapperspb.String(id)
+	g.unaryCall(w, r, g.downloadsService, "CreateURL", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return g.downloads.CreateURL(ctx, req.(*wrapperspb.StringValue))
+	})
+}
+
+// GET /v1/search?q={text}
+func (g *gateway) searchCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	in := wrapperspb.String(r.URL.Query().Get("q"))
+	g.serverStreamCall(w, r, g.searchService, "Search", func(stream grpc.ServerStream) error {
+		return g.search.Search(in, &searchSearchServer{stream})
+	})
+}
+
+// POST /v1/share-links
+func (g *gateway) shareLinksCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w, http.MethodPost)
+		return
+	}
+	in := &structpb.Struct{}
+	if !decodeBody(w, r, in) {
+		return
+	}
+	g.unaryCall(w, r, g.shareLinksService, "Create", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return g.shareLinks.Create(ctx, req.(*structpb.Struct))
+	})
+}
+
+// DELETE /v1/share-links/{id}
+func (g *gateway) shareLinksItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemID(w, r, GatewayPath+"share-links/")
+	if !ok {
+		return
+	}
+	if r.Method != http.MethodDelete {
+		methodNotAllowed(w, http.MethodDelete)
+		return
+	}
+	in := wrapperspb.String(id)
+	g.unaryCall(w, r, g.shareLinksService, "Revoke", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return g.shareLinks.Revoke(ctx, req.(*wrapperspb.StringValue))
+	})
+}
+
+// GET /v1/tags
+func (g *gateway) tagsCollection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	in := &emptypb.Empty{}
+	g.unaryCall(w, r, g.tagsService, "List", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return g.tags.List(ctx, req.(*emptypb.Empty))
+	})
+}
+
+// GET /v1/collections, POST /v1/collections
+func (g *gateway) collectionsCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		in := &emptypb.Empty{}
+		g.unaryCall(w, r, g.collectionsService, "List", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.collections.List(ctx, req.(*emptypb.Empty))
+		})
+	case http.MethodPost:
+		in := &structpb.Struct{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		g.unaryCall(w, r, g.collectionsService, "Create", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.collections.Create(ctx, req.(*structpb.Struct))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+// GET, PUT and DELETE /v1/collections/{id}
+func (g *gateway) collectionsItem(w http.ResponseWriter, r *http.Request) {
+	id, ok := itemID(w, r, GatewayPath+"collections/")
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		in := wrapperspb.String(id)
+		g.unaryCall(w, r, g.collectionsService, "Get", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.collections.Get(ctx, req.(*wrapperspb.StringValue))
+		})
+	case http.MethodPut:
+		in := &structpb.Struct{}
+		if !decodeBody(w, r, in) {
+			return
+		}
+		setStructField(in, "id", id)
+		g.unaryCall(w, r, g.collectionsService, "Update", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.collections.Update(ctx, req.(*structpb.Struct))
+		})
+	case http.MethodDelete:
+		in := wrapperspb.String(id)
+		g.unaryCall(w, r, g.collectionsService, "Delete", in, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return g.collections.Delete(ctx, req.(*wrapperspb.StringValue))
+		})
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+type projectsListServer struct {
+	grpc.ServerStream
+}
+
+func (x *projectsListServer) Send(m *projects.ProjectInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+type versionsListServer struct {
+	grpc.ServerStream
+}
+
+func (x *versionsListServer) Send(m *versions.VersionInfo) error {
+	return x.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// maximal size of a request body, requests only carry metadata
+const maxGatewayBody = 1 << 20
+
+var (
+	marshalOptions   = protojson.MarshalOptions{EmitUnpopulated: true}
+	unmarshalOptions = protojson.UnmarshalOptions{}
+)
+
+func (g *gateway) unaryCall(w http.ResponseWriter, r *http.Request, service, method string, in interface{}, handler grpc.UnaryHandler) {
+	transport := newGatewayTransport(w, service, method)
+	ctx := incomingContext(r, transport)
+
+	out, err := g.unary(ctx, in, &grpc.UnaryServerInfo{Server: g, FullMethod: transport.method}, handler)
+	// Unary answers are not streamed, so trailers are sent with headers
+	transport.header = metadata.Join(transport.header, transport.trailer)
+	if err != nil {
+		transport.writeError(err)
+		return
+	}
+	transport.writeMessage(out)
+}
+
+// serverStreamCall answers with one JSON message per line
+func (g *gateway) serverStreamCall(w http.ResponseWriter, r *http.Request, service, method string, handler func(grpc.ServerStream) error) {
+	transport := newGatewayTransport(w, service, method)
+	stream := &gatewayStream{
+		transport: transport,
+		ctx:       incomingContext(r, transport),
+	}
+
+	info := &grpc.StreamServerInfo{FullMethod: transport.method, IsServerStream: true}
+	err := g.stream(g, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return handler(stream)
+	})
+	if err != nil && !transport.wroteHeader {
+		transport.writeError(err)
+		transport.writeTrailer(false)
+		return
+	}
+	if err != nil {
+		// The status is already sent, so the error goes into the stream
+		transport.writeErrorLine(err)
+	} else {
+		transport.writeStatus(http.StatusOK, "application/x-ndjson")
+	}
+	transport.writeTrailer(true)
+}
+
+// incomingContext passes http headers to the handlers as grpc metadata, and
+// the connection as the grpc peer
+func incomingContext(r *http.Request, transport *gatewayTransport) context.Context {
+	md := metadata.MD{}
+	for key, values := range r.Header {
+		md.Append(strings.ToLower(key), values...)
+	}
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+
+	p := &peer.Peer{Addr: remoteAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	ctx = peer.NewContext(ctx, p)
+
+	return grpc.NewContextWithServerTransportStream(ctx, transport)
+}
+
+// remoteAddr is the address of an http client
+type remoteAddr string
+
+func (a remoteAddr) Network() string {
+	return "tcp"
+}
+
+func (a remoteAddr) String() string {
+	return string(a)
+}
+
+// gatewayTransport collects the metadata sent by handlers and writes it as http headers
+type gatewayTransport struct {
+	w           http.ResponseWriter
+	method      string
+	header      metadata.MD
+	trailer     metadata.MD
+	wroteHeader bool
+}
+
+func newGatewayTransport(w http.ResponseWriter, service, method string) *gatewayTransport {
+	return &gatewayTransport{
+		w:       w,
+		method:  fmt.Sprintf("/%s/%s", service, method),
+		header:  metadata.MD{},
+		trailer: metadata.MD{},
+	}
+}
+
+func (t *gatewayTransport) Method() string {
+	return t.method
+}
+
+func (t *gatewayTransport) SetHeader(md metadata.MD) error {
+	if t.wroteHeader {
+		return status.Error(codes.Internal, "headers are already sent")
+	}
+	t.header = metadata.Join(t.header, md)
+	return nil
+}
+
+func (t *gatewayTransport) SendHeader(md metadata.MD) error {
+	if err := t.SetHeader(md); err != nil {
+		return err
+	}
+	t.writeStatus(http.StatusOK, "application/x-ndjson")
+	return nil
+}
+
+func (t *gatewayTransport) SetTrailer(md metadata.MD) error {
+	t.trailer = metadata.Join(t.trailer, md)
+	return nil
+}
+
+func (t *gatewayTransport) writeStatus(code int, contentType string) {
+	if t.wroteHeader {
+		return
+	}
+	t.wroteHeader = true
+	for key, values := range t.header {
+		for _, value := range values {
+			t.w.Header().Add(key, value)
+		}
+	}
+	t.w.Header().Set("Content-Type", contentType)
+	t.w.WriteHeader(code)
+}
+
+// writeTrailer sends the trailers as http trailers and, at the end of a
+// stream, as a last line shaped like {"trailer": {"key": ["value"]}}, since
+// browsers and most http clients can't read http trailers
+func (t *gatewayTransport) writeTrailer(line bool) {
+	if len(t.trailer) == 0 {
+		return
+	}
+	for key, values := range t.trailer {
+		for _, value := range values {
+			t.w.Header().Add(http.TrailerPrefix+key, value)
+		}
+	}
+	if !line {
+		return
+	}
+	body, err := json.Marshal(map[string]metadata.MD{"trailer": t.trailer})
+	if err != nil {
+		logger.GetServerLogger().Error(err)
+		return
+	}
+	t.w.Write(append(body, '\n'))
+}
+
+func (t *gatewayTransport) writeMessage(m interface{}) {
+	body, err := marshal(m)
+	if err != nil {
+		t.writeError(status.Error(codes.Internal, err.Error()))
+		return
+	}
+	t.writeStatus(http.StatusOK, "application/json")
+	t.w.Write(body)
+}
+
+func (t *gatewayTransport) writeLine(m interface{}) error {
+	body, err := marshal(m)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	t.writeStatus(http.StatusOK, "application/x-ndjson")
+	if _, err := t.w.Write(append(body, '\n')); err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if flusher, ok := t.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (t *gatewayTransport) writeError(err error) {
+	st := status.Convert(err)
+	body, marshalErr := marshalOptions.Marshal(st.Proto())
+	if marshalErr != nil {
+		logger.GetServerLogger().Error(marshalErr)
+		http.Error(t.w, st.Message(), httpStatusFromCode(st.Code()))
+		return
+	}
+	t.writeStatus(httpStatusFromCode(st.Code()), "application/json")
+	t.w.Write(body)
+}
+
+func (t *gatewayTransport) writeErrorLine(err error) {
+	body, marshalErr := marshalOptions.Marshal(status.Convert(err).Proto())
+	if marshalErr != nil {
+		logger.GetServerLogger().Error(marshalErr)
+		return
+	}
+	t.w.Write([]byte(`{"error":` + string(body) + "}\n"))
+}
+
+// gatewayStream is the grpc.ServerStream of streaming calls
+type gatewayStream struct {
+	transport *gatewayTransport
+	ctx       context.Context
+}
+
+func (s *gatewayStream) SetHeader(md metadata.MD) error {
+	return s.transport.SetHeader(md)
+}
+
+func (s *gatewayStream) SendHeader(md metadata.MD) error {
+	return s.transport.SendHeader(md)
+}
+
+func (s *gatewayStream) SetTrailer(md metadata.MD) {
+	s.transport.SetTrailer(md)
+}
+
+func (s *gatewayStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *gatewayStream) SendMsg(m interface{}) error {
+	return s.transport.writeLine(m)
+}
+
+func (s *gatewayStream) RecvMsg(m interface{}) error {
+	return io.EOF
+}
+
+func marshal(m interface{}) ([]byte, error) {
+	switch m := m.(type) {
+	case proto.Message:
+		return marshalOptions.Marshal(m)
+	default:
+		return json.Marshal(m)
+	}
+}
+
+// decodeBody reads a JSON request body into in, it answers the request on failure
+func decodeBody(w http.ResponseWriter, r *http.Request, in interface{}) bool {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxGatewayBody))
+	if err != nil {
+		writeBadRequest(w, err)
+		return false
+	}
+	if len(body) == 0 {
+		return true
+	}
+	if m, ok := in.(proto.Message); ok {
+		err = unmarshalOptions.Unmarshal(body, m)
+	} else {
+		err = json.Unmarshal(body, in)
+	}
+	if err != nil {
+		writeBadRequest(w, err)
+		return false
+	}
+	return true
+}
+
+// decodeQuery sets fields of in from query parameters, nested fields are
+// addressed with dots, like paging.count=10. It answers the request on failure.
+func decodeQuery(w http.ResponseWriter, r *http.Request, in proto.Message) bool {
+	for key, values := range r.URL.Query() {
+		if err := setQueryField(in.ProtoReflect(), key, strings.Split(key, "."), values); err != nil {
+			writeBadRequest(w, err)
+			return false
+		}
+	}
+	return true
+}
+
+func setQueryField(m protoreflect.Message, key string, path []string, values []string) error {
+	fields := m.Descriptor().Fields()
+	field := fields.ByName(protoreflect.Name(path[0]))
+	if field == nil {
+		field = fields.ByJSONName(path[0])
+	}
+	if field == nil || field.IsMap() {
+		return fmt.Errorf("unknown query parameter: %s", key)
+	}
+
+	if len(path) > 1 {
+		if field.Kind() != protoreflect.MessageKind || field.IsList() {
+			return fmt.Errorf("unknown query parameter: %s", key)
+		}
+		return setQueryField(m.Mutable(field).Message(), key, path[1:], values)
+	}
+
+	if field.IsList() {
+		list := m.Mutable(field).List()
+		for _, value := range values {
+			v, err := parseQueryValue(field, value)
+			if err != nil {
+				return fmt.Errorf("invalid query parameter %s: %v", key, err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+	v, err := parseQueryValue(field, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("invalid query parameter %s: %v", key, err)
+	}
+	m.Set(field, v)
+	return nil
+}
+
+func parseQueryValue(field protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(value)), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.EnumKind:
+		if v := field.Enum().Values().ByName(protoreflect.Name(value)); v != nil {
+			return protoreflect.ValueOfEnum(v.Number()), nil
+		}
+		v, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	default:
+		return protoreflect.Value{}, fmt.Errorf("%s fields can't be set from the query", field.Kind())
+	}
+}
+
+// itemID reads the id following prefix in the path, it answers the request on failure
+func itemID(w http.ResponseWriter, r *http.Request, prefix string) (string, bool) {
+	id := strings.TrimPrefix(r.URL.Path, prefix)
+	if id == "" || strings.Contains(id, "/") {
+		http.NotFound(w, r)
+		return "", false
+	}
+	return id, true
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeGatewayError(w, status.Error(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed)), http.StatusMethodNotAllowed)
+}
+
+func writeBadRequest(w http.ResponseWriter, err error) {
+	writeGatewayError(w, status.Error(codes.InvalidArgument, err.Error()), http.StatusBadRequest)
+}
+
+func writeGatewayError(w http.ResponseWriter, err error, code int) {
+	body, _ := marshalOptions.Marshal(status.Convert(err).Proto())
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Creations can be retried safely when they send an idempotency key, it's the
+// Idempotency-Key header of the REST gateway. A retry with the same key gets
+// the response of the first call.
+const IdempotencyKey = "idempotency-key"
+
+// idempotencyKey reads the key of a creation, it's empty when none is sent
+func idempotencyKey(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(IdempotencyKey); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/droplez/droplez-studio/pkg/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// List calls are paged with cursors, they're the Cursor header and the
+// Next-Cursor trailer of the REST gateway. A list call sends the cursor of
+// the next page in its trailer, and the next call sends it back.
+const (
+	CursorKey     = "cursor"
+	NextCursorKey = "next-cursor"
+)
+
+// Project lists are narrowed by a filter and sorted by a field, they're the
+// Filter and Order-By headers of the REST gateway
+const (
+	FilterKey  = "filter"
+	OrderByKey = "order-by"
+)
+
+// The trailer of a list holds fields for each item, and grpc clients
+// commonly refuse more than 8 KiB of metadata, so their pages are capped for
+// the trailer to fit. Fields are counted like http/2 does, 32 bytes on top of
+// the key and the value. The cursor and the status get trailerReserve, plus
+// room for the filter and order the cursor holds. The REST gateway writes
+// the trailer in the body, its pages aren't capped.
+const (
+	maxTrailerSize = 8 << 10
+	trailerReserve = 1 << 10
+)
+
+// Sizes of the values of trailer fields, ids are uuids, etags hold int64
+// revisions and times are RFC 3339 with nanoseconds
+var (
+	trailerIDSize   = len("00000000-0000-0000-0000-000000000000")
+	trailerETagSize = len(formatETag(math.MinInt64))
+	trailerTimeSize = len("2006-01-02T15:04:05.999999999Z")
+)
+
+// trailerFieldSize is the size of an "<id>=<value>" field of a list trailer
+func trailerFieldSize(key string, valueSize int) int {
+	return len(key) + trailerIDSize + len("=") + valueSize + 32
+}
+
+// Trailer sizes of a listed item, its revision and the timestamps of projects
+var (
+	projectTrailerSize = trailerFieldSize(RevisionsKey, trailerETagSize) +
+		trailerFieldSize(CreatedAtKey, trailerTimeSize) +
+		trailerFieldSize(UpdatedAtKey, trailerTimeSize) +
+		trailerFieldSize(LastVersionAtKey, trailerTimeSize)
+	versionTrailerSize = trailerFieldSize(RevisionsKey, trailerETagSize)
+)
+
+// listParams reads the options of a list that are sent as metadata, grpc
+// pages are capped for the trailer fields of their items, of itemSize
+func listParams(ctx context.Context, itemSize int) service.ListParams {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	params := service.ListParams{
+		Cursor: first(CursorKey),
+		// Conditions sent in more than one value all apply
+		Filter:  strings.Join(md.Get(FilterKey), ","),
+		OrderBy: first(OrderByKey),
+	}
+	if _, gateway := grpc.ServerTransportStreamFromContext(ctx).(*gatewayTransport); itemSize > 0 && !gateway {
+		params.MaxPageSize = maxPageSize(itemSize, params)
+	}
+	return params
+}
+
+// maxPageSize is how many items of itemSize fit the trailer of a page, the
+// cursor holds the filter and order base64 encoded, in JSON
+func maxPageSize(itemSize int, params service.ListParams) int {
+	cursorSize := 2 * (len(params.Filter) + len(params.OrderBy))
+	size := (maxTrailerSize - trailerReserve - cursorSize) / itemSize
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// listTrailer is the trailer of a list, with the revisions of the listed
+// items and the cursor of the next page
+func listTrailer(revisions []string, next string) metadata.MD {
+	trailer := metadata.MD{}
+	if len(revisions) > 0 {
+		trailer.Set(RevisionsKey, revisions...)
+	}
+	if next != "" {
+		trailer.Set(NextCursorKey, next)
+	}
+	return trailer
+}
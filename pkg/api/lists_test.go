@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/metadata"
+)
+
+// The trailers of the largest grpc pages fit the metadata limit of clients,
+// with the largest values of every field
+func TestListTrailerSize(t *testing.T) {
+	at := time.Date(2026, 10, 19, 12, 47, 18, 123456789, time.UTC)
+	for _, params := range []service.ListParams{
+		{},
+		{Filter: "daw=ABLETON,bpm>=120,bpm<=128,key=harmonic:" + uuid.New().String(), OrderBy: "last_version_at desc"},
+		{Filter: strings.Repeat("genre=ambient,", 100)},
+	} {
+		size := maxPageSize(projectTrailerSize, params)
+		revisions := &projectRevisionsStream{Projects_ListServer: discardProjects{}}
+		timestamps := &projectTimestampsStream{ProjectStream: revisions}
+		for i := 0; i < size; i++ {
+			project := &models.Project{
+				ProjectInfo:   &projects.ProjectInfo{Id: &projects.ProjectId{Id: uuid.New().String()}},
+				Revision:      math.MinInt64,
+				CreatedAt:     at,
+				UpdatedAt:     at,
+				LastVersionAt: &at,
+			}
+			if err := timestamps.Send(project); err != nil {
+				t.Fatal(err)
+			}
+		}
+		list := "projects?" + params.Filter + "&" + params.OrderBy
+		trailer := metadata.Join(listTrailer(revisions.revisions, testCursor(list, at)), timestamps.trailer)
+		if got := trailerSize(trailer); got > maxTrailerSize {
+			t.Errorf("a page of %d projects with filter %q sends a trailer of %d bytes, over %d", size, params.Filter, got, maxTrailerSize)
+		}
+	}
+
+	size := maxPageSize(versionTrailerSize, service.ListParams{})
+	revisions := &versionRevisionsStream{Versions_ListServer: discardVersions{}}
+	for i := 0; i < size; i++ {
+		version := &models.Version{
+			VersionInfo: &versions.VersionInfo{Id: &versions.VersionId{Id: uuid.New().String()}},
+			Revision:    math.MinInt64,
+		}
+		if err := revisions.Send(version); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := trailerSize(listTrailer(revisions.revisions, testCursor("versions", nil))); got > maxTrailerSize {
+		t.Errorf("a page of %d versions sends a trailer of %d bytes, over %d", size, got, maxTrailerSize)
+	}
+}
+
+// trailerSize counts a trailer like http/2 does, with the status grpc adds
+func trailerSize(trailer metadata.MD) int {
+	size := len("grpc-status") + len("0") + 32
+	for key, values := range trailer {
+		for _, value := range values {
+			size += len(key) + len(value) + 32
+		}
+	}
+	return size
+}
+
+// testCursor is a cursor as large as the pager makes them
+func testCursor(list string, value interface{}) string {
+	payload, _ := json.Marshal(map[string]interface{}{"l": list, "a": uuid.New().String(), "v": value})
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(make([]byte, 32))
+}
+
+type discardProjects struct {
+	projects.Projects_ListServer
+}
+
+func (discardProjects) Send(*projects.ProjectInfo) error {
+	return nil
+}
+
+type discardVersions struct {
+	versions.Versions_ListServer
+}
+
+func (discardVersions) Send(*versions.VersionInfo) error {
+	return nil
+}
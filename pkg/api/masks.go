@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Partial updates send the fields they write as an update mask, it's the
+// Update-Mask header of the REST gateway. Paths are comma separated, like
+// "metadata.name,metadata.bpm".
+const UpdateMaskKey = "update-mask"
+
+// updateMask reads the mask of an update, it's nil when none is sent and the
+// whole resource is replaced
+func updateMask(ctx context.Context) (*fieldmaskpb.FieldMask, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(UpdateMaskKey)
+	if len(values) == 0 {
+		return nil, nil
+	}
+	mask := &fieldmaskpb.FieldMask{}
+	for _, value := range values {
+		for _, path := range strings.Split(value, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				mask.Paths = append(mask.Paths, path)
+			}
+		}
+	}
+	if len(mask.Paths) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "empty %s", UpdateMaskKey)
+	}
+	return mask, nil
+}
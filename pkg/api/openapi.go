@@ -0,0 +1,19 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPI describes the REST gateway, it's maintained by hand next to gateway.go
+//
+//go:embed openapi.yaml
+var openAPI []byte
+
+// OpenAPIHandler serves the OpenAPI document of the REST gateway
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write(openAPI)
+	})
+}
@@ -8,41 +8,71 @@ import (
 	"github.com/droplez/droplez-studio/pkg/service"
 	"github.com/droplez/droplez-studio/tools/logger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 type projectsGrpcImpl struct {
 	projects.UnimplementedProjectsServer
+	service *service.ProjectService
 }
 
-func RegisterProjectsServer(grpcServer *grpc.Server) {
-	projects.RegisterProjectsServer(grpcServer, &projectsGrpcImpl{})
+func RegisterProjectsServer(grpcServer *grpc.Server, projectService *service.ProjectService) {
+	projects.RegisterProjectsServer(grpcServer, &projectsGrpcImpl{service: projectService})
 }
 
 func (s projectsGrpcImpl) Create(ctx context.Context, in *projects.ProjectMeta) (*projects.ProjectInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.ProjectCreate(ctx, in)
+	out, err := s.service.Create(ctx, in, idempotencyKey(ctx))
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	setTimestamps(ctx, out)
+	return out.ProjectInfo, nil
 }
 
 func (s projectsGrpcImpl) Update(ctx context.Context, in *projects.ProjectInfo) (*projects.ProjectInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.ProjectUpdate(ctx, in)
+	revision, err := ifMatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := updateMask(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.service.Update(ctx, in, revision, mask)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	setTimestamps(ctx, out)
+	return out.ProjectInfo, nil
 }
 
 func (s projectsGrpcImpl) Get(ctx context.Context, in *projects.ProjectId) (*projects.ProjectInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.ProjectGet(ctx, in)
+	out, err := s.service.Get(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	setTimestamps(ctx, out)
+	return out.ProjectInfo, nil
 }
 
 func (s projectsGrpcImpl) Delete(ctx context.Context, in *projects.ProjectInfo) (*common.EmptyMessage, error) {
 	logger.EndpointHit(ctx)
-	return service.ProjectDelete(ctx, in)
+	return s.service.Delete(ctx, in)
 }
 
 func (s projectsGrpcImpl) List(in *projects.ListOptions, stream projects.Projects_ListServer) (err error) {
 	logger.EndpointHit(stream.Context())
-	err = service.ProjectsList(stream.Context(), stream, in)
-	if err != nil {
-		return
+	revisions := &projectRevisionsStream{Projects_ListServer: stream}
+	timestamps := &projectTimestampsStream{ProjectStream: revisions}
+	next, err := s.service.List(stream.Context(), timestamps, in, listParams(stream.Context(), projectTrailerSize))
+	if trailer := metadata.Join(listTrailer(revisions.revisions, next), timestamps.trailer); trailer.Len() > 0 {
+		stream.SetTrailer(trailer)
 	}
-	return
+	return err
 }
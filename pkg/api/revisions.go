@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Revisions are sent as etags, they're the ETag and If-Match headers of the
+// REST gateway. List calls send one "<id>=<etag>" revisions trailer per item.
+const (
+	ETagKey      = "etag"
+	IfMatchKey   = "if-match"
+	RevisionsKey = "revisions"
+)
+
+func formatETag(revision int64) string {
+	return strconv.Quote(strconv.FormatInt(revision, 10))
+}
+
+func parseETag(etag string) (int64, error) {
+	if unquoted, err := strconv.Unquote(etag); err == nil {
+		etag = unquoted
+	}
+	return strconv.ParseInt(etag, 10, 64)
+}
+
+// setETag sends the revision of the returned resource as a header
+func setETag(ctx context.Context, revision int64) {
+	if err := grpc.SetHeader(ctx, metadata.Pairs(ETagKey, formatETag(revision))); err != nil {
+		logger.GetGrpcLogger(ctx).Error(err)
+	}
+}
+
+// ifMatch reads the revision an update is made at, it's zero when none is sent
+func ifMatch(ctx context.Context) (int64, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(IfMatchKey)
+	if len(values) == 0 {
+		return 0, nil
+	}
+	revision, err := parseETag(strings.TrimSpace(values[0]))
+	if err != nil || revision <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s etag: %s", IfMatchKey, values[0])
+	}
+	return revision, nil
+}
+
+// projectRevisionsStream sends the listed projects, and keeps their
+// revisions for the trailer
+type projectRevisionsStream struct {
+	projects.Projects_ListServer
+	revisions []string
+}
+
+func (s *projectRevisionsStream) Send(project *models.Project) error {
+	s.revisions = append(s.revisions, project.GetId().GetId()+"="+formatETag(project.Revision))
+	return s.Projects_ListServer.Send(project.ProjectInfo)
+}
+
+// versionRevisionsStream sends the listed versions, and keeps their
+// revisions for the trailer
+type versionRevisionsStream struct {
+	versions.Versions_ListServer
+	revisions []string
+}
+
+func (s *versionRevisionsStream) Send(version *models.Version) error {
+	s.revisions = append(s.revisions, version.GetId().GetId()+"="+formatETag(version.Revision))
+	return s.Versions_ListServer.Send(version.VersionInfo)
+}
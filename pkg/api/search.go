@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type searchGrpcImpl struct {
+	service *service.SearchService
+}
+
+func RegisterSearchServer(grpcServer *grpc.Server, searchService *service.SearchService) {
+	grpcServer.RegisterService(&searchServiceDesc, &searchGrpcImpl{service: searchService})
+}
+
+// Search sends the projects and versions matching a text, best matches
+// first. Pages go on with cursors, like lists.
+func (s searchGrpcImpl) Search(in *wrapperspb.StringValue, stream *searchSearchServer) error {
+	logger.EndpointHit(stream.Context())
+	next, err := s.service.Search(stream.Context(), searchHitsStream{stream}, in.GetValue(), listParams(stream.Context(), 0))
+	if trailer := listTrailer(nil, next); trailer.Len() > 0 {
+		stream.SetTrailer(trailer)
+	}
+	return err
+}
+
+// searchHitsStream sends hits as structs
+type searchHitsStream struct {
+	*searchSearchServer
+}
+
+func (s searchHitsStream) Send(hit *models.SearchHit) error {
+	snippets := make(map[string]interface{}, len(hit.Snippets))
+	for field, snippet := range hit.Snippets {
+		snippets[field] = snippet
+	}
+	out, err := structpb.NewStruct(map[string]interface{}{
+		"kind":       hit.Kind,
+		"id":         hit.ID,
+		"project_id": hit.ProjectID,
+		"rank":       hit.Rank,
+		"snippets":   snippets,
+	})
+	if err != nil {
+		return err
+	}
+	return s.searchSearchServer.Send(out)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The Search service only uses well-known types, like the Downloads one.
+// A hit is a struct with the fields kind, id, project_id, rank and snippets.
+//
+//	service Search {
+//	  rpc Search(google.protobuf.StringValue) returns (stream google.protobuf.Struct);
+//	}
+const searchProtoFile = "droplez/studio/search.proto"
+
+func init() {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(searchProtoFile),
+		Package:    proto.String("droplez.studio.search"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Search"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:            proto.String("Search"),
+				InputType:       proto.String(".google.protobuf.StringValue"),
+				OutputType:      proto.String(".google.protobuf.Struct"),
+				ServerStreaming: proto.Bool(true),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(file); err != nil {
+		panic(err)
+	}
+}
+
+type searchServer interface {
+	Search(*wrapperspb.StringValue, *searchSearchServer) error
+}
+
+var searchServiceDesc = grpc.ServiceDesc{
+	ServiceName: "droplez.studio.search.Search",
+	HandlerType: (*searchServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Search",
+			Handler:       searchSearchHandler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: searchProtoFile,
+}
+
+func searchSearchHandler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(searchServer).Search(in, &searchSearchServer{stream})
+}
+
+type searchSearchServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchSearchServer) Send(m *structpb.Struct) error {
+	return x.ServerStream.SendMsg(m)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type shareLinksGrpcImpl struct {
+	service *service.ShareLinkService
+}
+
+func RegisterShareLinksServer(grpcServer *grpc.Server, shareLinkService *service.ShareLinkService) {
+	grpcServer.RegisterService(&shareLinksServiceDesc, &shareLinksGrpcImpl{service: shareLinkService})
+}
+
+// Create shares a project or one of its versions, the returned link holds
+// the token, which can't be read again afterwards
+func (s shareLinksGrpcImpl) Create(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	link := &models.ShareLink{}
+	if err := fromStruct(in, link); err != nil {
+		return nil, err
+	}
+	out, err := s.service.Create(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+// Revoke disables a share link for good, it takes the id of the link
+func (s shareLinksGrpcImpl) Revoke(ctx context.Context, in *wrapperspb.StringValue) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	if err := s.service.Revoke(ctx, in.GetValue()); err != nil {
+		return nil, err
+	}
+	return toStruct(&models.ShareLink{ID: in.GetValue(), Revoked: true})
+}
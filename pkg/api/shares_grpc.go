@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The ShareLinks service only uses well-known types, share links have no
+// messages in droplez-go-proto. A share link is a struct with its JSON fields.
+//
+//	service ShareLinks {
+//	  rpc Create(google.protobuf.Struct) returns (google.protobuf.Struct);
+//	  rpc Revoke(google.protobuf.StringValue) returns (google.protobuf.Struct);
+//	}
+const shareLinksProtoFile = "droplez/studio/share_links.proto"
+
+func init() {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(shareLinksProtoFile),
+		Package:    proto.String("droplez.studio.sharelinks"),
+		Dependency: []string{"google/protobuf/wrappers.proto", "google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("ShareLinks"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Create"),
+					InputType:  proto.String(".google.protobuf.Struct"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("Revoke"),
+					InputType:  proto.String(".google.protobuf.StringValue"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(file); err != nil {
+		panic(err)
+	}
+}
+
+type shareLinksServer interface {
+	Create(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	Revoke(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+}
+
+var shareLinksServiceDesc = grpc.ServiceDesc{
+	ServiceName: "droplez.studio.sharelinks.ShareLinks",
+	HandlerType: (*shareLinksServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Create",
+			Handler:    shareLinksCreateHandler,
+		},
+		{
+			MethodName: "Revoke",
+			Handler:    shareLinksRevokeHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: shareLinksProtoFile,
+}
+
+func shareLinksCreateHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(shareLinksServer).Create(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.sharelinks.ShareLinks/Create",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(shareLinksServer).Create(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func shareLinksRevokeHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(shareLinksServer).Revoke(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.sharelinks.ShareLinks/Revoke",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(shareLinksServer).Revoke(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Models without proto messages, like share links, go through grpc as
+// structs holding their JSON fields
+
+// toStruct turns a model into the struct of its JSON fields
+func toStruct(m interface{}) (*structpb.Struct, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(fields)
+}
+
+// toList turns a slice of models into a list of the structs of their JSON fields
+func toList(m interface{}) (*structpb.ListValue, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, err
+	}
+	return structpb.NewList(values)
+}
+
+// setStructField sets a string field of a struct, like the id of the path of
+// a REST resource
+func setStructField(in *structpb.Struct, field, value string) {
+	if in.Fields == nil {
+		in.Fields = map[string]*structpb.Value{}
+	}
+	in.Fields[field] = structpb.NewStringValue(value)
+}
+
+// fromStruct reads a model from the struct of its JSON fields, like from a
+// JSON request body
+func fromStruct(in *structpb.Struct, m interface{}) error {
+	body, err := protojson.Marshal(in)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := json.Unmarshal(body, m); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
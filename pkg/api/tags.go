@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type tagsGrpcImpl struct {
+	service *service.TagService
+}
+
+func RegisterTagsServer(grpcServer *grpc.Server, tagService *service.TagService) {
+	grpcServer.RegisterService(&tagsServiceDesc, &tagsGrpcImpl{service: tagService})
+}
+
+// Get returns the tags of a project, it takes the id of the project
+func (s tagsGrpcImpl) Get(ctx context.Context, in *wrapperspb.StringValue) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	out, err := s.service.Get(ctx, in.GetValue())
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+// Set replaces the tags of a project, they're returned lower case and sorted
+func (s tagsGrpcImpl) Set(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	logger.EndpointHit(ctx)
+	tags := &models.ProjectTags{}
+	if err := fromStruct(in, tags); err != nil {
+		return nil, err
+	}
+	out, err := s.service.Set(ctx, tags)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(out)
+}
+
+// List returns every tag in use with its number of projects
+func (s tagsGrpcImpl) List(ctx context.Context, _ *emptypb.Empty) (*structpb.ListValue, error) {
+	logger.EndpointHit(ctx)
+	out, err := s.service.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return toList(out)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// The Tags service only uses well-known types, like the Downloads one. The
+// tags of a project are a struct with the JSON fields of the REST resource,
+// the tags in use a list of structs with the fields tag and projects. Get
+// takes the id of a project.
+//
+//	service Tags {
+//	  rpc Get(google.protobuf.StringValue) returns (google.protobuf.Struct);
+//	  rpc Set(google.protobuf.Struct) returns (google.protobuf.Struct);
+//	  rpc List(google.protobuf.Empty) returns (google.protobuf.ListValue);
+//	}
+const tagsProtoFile = "droplez/studio/tags.proto"
+
+func init() {
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String(tagsProtoFile),
+		Package:    proto.String("droplez.studio.tags"),
+		Dependency: []string{"google/protobuf/empty.proto", "google/protobuf/wrappers.proto", "google/protobuf/struct.proto"},
+		Syntax:     proto.String("proto3"),
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Tags"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{
+					Name:       proto.String("Get"),
+					InputType:  proto.String(".google.protobuf.StringValue"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("Set"),
+					InputType:  proto.String(".google.protobuf.Struct"),
+					OutputType: proto.String(".google.protobuf.Struct"),
+				},
+				{
+					Name:       proto.String("List"),
+					InputType:  proto.String(".google.protobuf.Empty"),
+					OutputType: proto.String(".google.protobuf.ListValue"),
+				},
+			},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		panic(err)
+	}
+	if err := protoregistry.GlobalFiles.RegisterFile(file); err != nil {
+		panic(err)
+	}
+}
+
+type tagsServer interface {
+	Get(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+	Set(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	List(context.Context, *emptypb.Empty) (*structpb.ListValue, error)
+}
+
+var tagsServiceDesc = grpc.ServiceDesc{
+	ServiceName: "droplez.studio.tags.Tags",
+	HandlerType: (*tagsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    tagsGetHandler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    tagsSetHandler,
+		},
+		{
+			MethodName: "List",
+			Handler:    tagsListHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: tagsProtoFile,
+}
+
+func tagsGetHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(tagsServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.tags.Tags/Get",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(tagsServer).Get(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func tagsSetHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(tagsServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.tags.Tags/Set",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(tagsServer).Set(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func tagsListHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(tagsServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/droplez.studio.tags.Tags/List",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(tagsServer).List(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
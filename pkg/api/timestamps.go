@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The timestamps of a project aren't in its message, they're sent as
+// headers in RFC 3339, in UTC. List calls send one "<id>=<time>" trailer of
+// each per item, last-version-at only for projects with versions.
+const (
+	CreatedAtKey     = "created-at"
+	UpdatedAtKey     = "updated-at"
+	LastVersionAtKey = "last-version-at"
+)
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// setTimestamps sends the timestamps of the returned project as headers
+func setTimestamps(ctx context.Context, project *models.Project) {
+	header := metadata.Pairs(
+		CreatedAtKey, formatTime(project.CreatedAt),
+		UpdatedAtKey, formatTime(project.UpdatedAt),
+	)
+	if project.LastVersionAt != nil {
+		header.Set(LastVersionAtKey, formatTime(*project.LastVersionAt))
+	}
+	if err := grpc.SetHeader(ctx, header); err != nil {
+		logger.GetGrpcLogger(ctx).Error(err)
+	}
+}
+
+// projectTimestampsStream sends the listed projects, and keeps their
+// timestamps for the trailer
+type projectTimestampsStream struct {
+	models.ProjectStream
+	trailer metadata.MD
+}
+
+func (s *projectTimestampsStream) Send(project *models.Project) error {
+	if s.trailer == nil {
+		s.trailer = metadata.MD{}
+	}
+	id := project.GetId().GetId()
+	s.trailer.Append(CreatedAtKey, id+"="+formatTime(project.CreatedAt))
+	s.trailer.Append(UpdatedAtKey, id+"="+formatTime(project.UpdatedAt))
+	if project.LastVersionAt != nil {
+		s.trailer.Append(LastVersionAtKey, id+"="+formatTime(*project.LastVersionAt))
+	}
+	return s.ProjectStream.Send(project)
+}
@@ -12,38 +12,63 @@ import (
 
 type versionsGrpcImpl struct {
 	versions.UnimplementedVersionsServer
+	service *service.VersionService
 }
 
-func RegisterVersionsServer(grpcServer *grpc.Server) {
-	versions.RegisterVersionsServer(grpcServer, &versionsGrpcImpl{})
+func RegisterVersionsServer(grpcServer *grpc.Server, versionService *service.VersionService) {
+	versions.RegisterVersionsServer(grpcServer, &versionsGrpcImpl{service: versionService})
 }
 
 func (s versionsGrpcImpl) Create(ctx context.Context, in *versions.VersionMeta) (*versions.VersionInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.VersionCreate(ctx, in)
+	out, err := s.service.Create(ctx, in, idempotencyKey(ctx))
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	return out.VersionInfo, nil
 }
 
 func (s versionsGrpcImpl) Update(ctx context.Context, in *versions.VersionInfo) (*versions.VersionInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.VersionUpdate(ctx, in)
+	revision, err := ifMatch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := updateMask(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.service.Update(ctx, in, revision, mask)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	return out.VersionInfo, nil
 }
 
 func (s versionsGrpcImpl) Get(ctx context.Context, in *versions.VersionId) (*versions.VersionInfo, error) {
 	logger.EndpointHit(ctx)
-	return service.VersionGet(ctx, in)
+	out, err := s.service.Get(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	setETag(ctx, out.Revision)
+	return out.VersionInfo, nil
 }
 
 func (s versionsGrpcImpl) List(in *versions.ListOptions, stream versions.Versions_ListServer) (err error) {
 	logger.EndpointHit(stream.Context())
-	err = service.VersionsList(stream.Context(), stream, in)
-	if err != nil {
-		return
+	revisions := &versionRevisionsStream{Versions_ListServer: stream}
+	next, err := s.service.List(stream.Context(), revisions, in, listParams(stream.Context(), versionTrailerSize))
+	if trailer := listTrailer(revisions.revisions, next); trailer.Len() > 0 {
+		stream.SetTrailer(trailer)
 	}
-	return
+	return err
 }
 
 func (s versionsGrpcImpl) Delete(ctx context.Context, in *versions.VersionInfo) (*common.EmptyMessage, error) {
 	logger.EndpointHit(ctx)
-	return service.VersionDelete(ctx, in)
+	return s.service.Delete(ctx, in)
 
 }
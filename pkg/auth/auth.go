@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"path"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys that authenticate a call with a share link
+const (
+	ShareTokenKey    = "x-share-token"
+	SharePasswordKey = "x-share-password"
+)
+
+// Grant limits a caller authenticated by a share link to one project,
+// or to one version of it
+type Grant struct {
+	LinkID    string
+	ProjectID string
+	// VersionID is empty when the whole project is shared
+	VersionID string
+}
+
+// AllowsProject reports whether the project can be read with the grant
+func (g *Grant) AllowsProject(projectID string) bool {
+	return g.ProjectID == projectID
+}
+
+// AllowsVersion reports whether the version can be read with the grant
+func (g *Grant) AllowsVersion(projectID, versionID string) bool {
+	if g.ProjectID != projectID {
+		return false
+	}
+	return g.VersionID == "" || g.VersionID == versionID
+}
+
+// Identity of a caller that presented a verified client certificate
+type Identity struct {
+	CommonName     string
+	Organization   []string
+	DNSNames       []string
+	EmailAddresses []string
+}
+
+// ShareResolver turns a share token and an optional password into a grant
+type ShareResolver func(ctx context.Context, token, password string) (*Grant, error)
+
+type (
+	grantKey    struct{}
+	identityKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying the grant
+func NewContext(ctx context.Context, grant *Grant) context.Context {
+	return context.WithValue(ctx, grantKey{}, grant)
+}
+
+// GrantFromContext returns the share link grant of the caller, nil means
+// the call is not restricted by a share link
+func GrantFromContext(ctx context.Context) *Grant {
+	grant, _ := ctx.Value(grantKey{}).(*Grant)
+	return grant
+}
+
+// IdentityFromContext returns the client certificate identity of the caller,
+// nil means the caller didn't present a verified certificate
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// peerIdentity reads the identity from the verified client certificate of the connection
+func peerIdentity(ctx context.Context) *Identity {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	return &Identity{
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+	}
+}
+
+// AuthorizeProject checks that the caller may read the project
+func AuthorizeProject(ctx context.Context, projectID string) error {
+	if grant := GrantFromContext(ctx); grant != nil && !grant.AllowsProject(projectID) {
+		return errNotShared
+	}
+	return nil
+}
+
+// AuthorizeVersion checks that the caller may read the version
+func AuthorizeVersion(ctx context.Context, projectID, versionID string) error {
+	if grant := GrantFromContext(ctx); grant != nil && !grant.AllowsVersion(projectID, versionID) {
+		return errNotShared
+	}
+	return nil
+}
+
+// AuthorizeAll checks that the caller may read data of every project, like
+// tags or collections, which share links never allow
+func AuthorizeAll(ctx context.Context) error {
+	if GrantFromContext(ctx) != nil {
+		return errNotShared
+	}
+	return nil
+}
+
+// AuthorizeWrite checks that the caller may modify data, which share links never allow
+func AuthorizeWrite(ctx context.Context) error {
+	if GrantFromContext(ctx) != nil {
+		return errReadOnly
+	}
+	return nil
+}
+
+// Authenticate reads the client certificate identity of the incoming call,
+// resolves its share token, if there is one, and checks that the method is
+// allowed with it
+func Authenticate(ctx context.Context, fullMethod string, resolve ShareResolver) (context.Context, error) {
+	if identity := peerIdentity(ctx); identity != nil {
+		ctx = context.WithValue(ctx, identityKey{}, identity)
+		grpc_ctxtags.Extract(ctx).Set("auth.identity", identity.CommonName)
+	}
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+	token := firstValue(md, ShareTokenKey)
+	if token == "" {
+		return ctx, nil
+	}
+	if !readOnlyMethods[path.Base(fullMethod)] {
+		return ctx, errReadOnly
+	}
+	grant, err := resolve(ctx, token, firstValue(md, SharePasswordKey))
+	if err != nil {
+		return ctx, err
+	}
+	return NewContext(ctx, grant), nil
+}
+
+// UnaryServerInterceptor authenticates unary calls
+func UnaryServerInterceptor(resolve ShareResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := Authenticate(ctx, info.FullMethod, resolve)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates streaming calls
+func StreamServerInterceptor(resolve ShareResolver) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := Authenticate(stream.Context(), info.FullMethod, resolve)
+		if err != nil {
+			return err
+		}
+		wrapped := grpc_middleware.WrapServerStream(stream)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// methods that can be called with a share link
+var readOnlyMethods = map[string]bool{
+	"Get":       true,
+	"List":      true,
+	"CreateURL": true,
+	"Search":    true,
+}
+
+// Local errors
+var (
+	errNotShared = status.Error(codes.PermissionDenied, "resource is not shared with this link")
+	errReadOnly  = status.Error(codes.PermissionDenied, "share links grant read-only access")
+)
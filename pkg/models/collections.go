@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Collection is a named list of projects in the order the user gives them,
+// like the tracks of an EP. Names are unique.
+type Collection struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// ProjectIDs are the projects of the collection, in their order
+	ProjectIDs []string  `json:"project_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrorKind tells what went wrong, the service layer maps each kind to a
+// grpc code
+type ErrorKind int
+
+const (
+	// KindNotFound is a resource that doesn't exist
+	KindNotFound ErrorKind = iota + 1
+	// KindConflict is a resource, or one of its unique fields, that's taken
+	KindConflict
+	// KindStale is an update of a resource that was updated in the meantime
+	KindStale
+	// KindPrecondition is a resource that's not in a state allowing the call
+	KindPrecondition
+	// KindPermission is a call the caller isn't allowed to make
+	KindPermission
+	// KindAborted is a call that ran into another one on the same resource,
+	// it can be retried
+	KindAborted
+	// KindUnauthenticated is a caller whose credentials can't be verified
+	KindUnauthenticated
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindStale:
+		return "stale"
+	case KindPrecondition:
+		return "precondition"
+	case KindPermission:
+		return "permission"
+	case KindAborted:
+		return "aborted"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	}
+	return fmt.Sprintf("kind %d", int(k))
+}
+
+// Error is a domain error, stores return it for every failure that's not a
+// fault of the database. Its message is meant for the caller.
+type Error struct {
+	Kind ErrorKind
+	// Resource is the type of the resource, like "project"
+	Resource string
+	// ID of the resource, it's empty when it's not known
+	ID string
+	// Revision is the current revision of the resource of a KindStale error
+	Revision int64
+	Message  string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// KindOf returns the kind of a domain error, or zero for any other error
+func KindOf(err error) ErrorKind {
+	var domain *Error
+	if errors.As(err, &domain) {
+		return domain.Kind
+	}
+	return 0
+}
+
+// NotFoundError is a resource that can't be found by its id
+func NotFoundError(resource, id string) error {
+	return &Error{
+		Kind:     KindNotFound,
+		Resource: resource,
+		ID:       id,
+		Message:  fmt.Sprintf("%s with this id can not be found: %s", resource, id),
+	}
+}
+
+// ExistsError is a resource created with an id that's taken
+func ExistsError(resource, id string) error {
+	return &Error{
+		Kind:     KindConflict,
+		Resource: resource,
+		ID:       id,
+		Message:  fmt.Sprintf("%s with this id already exists: %s", resource, id),
+	}
+}
+
+// ConflictError is a unique field of a resource that's taken
+func ConflictError(resource, id, format string, args ...interface{}) error {
+	return &Error{Kind: KindConflict, Resource: resource, ID: id, Message: fmt.Sprintf(format, args...)}
+}
+
+// StaleError is an update made at another revision than the current one
+func StaleError(resource, id string, revision int64) error {
+	return &Error{
+		Kind:     KindStale,
+		Resource: resource,
+		ID:       id,
+		Revision: revision,
+		Message:  fmt.Sprintf("%s %s was updated in the meantime, it's at revision %d", resource, id, revision),
+	}
+}
+
+// PreconditionError is a resource that's not in a state allowing the call
+func PreconditionError(resource, id, format string, args ...interface{}) error {
+	return &Error{Kind: KindPrecondition, Resource: resource, ID: id, Message: fmt.Sprintf(format, args...)}
+}
+
+// PermissionError is a call the caller isn't allowed to make on a resource
+func PermissionError(resource, id, format string, args ...interface{}) error {
+	return &Error{Kind: KindPermission, Resource: resource, ID: id, Message: fmt.Sprintf(format, args...)}
+}
+
+// AbortedError is a call that ran into another one on the same resource
+func AbortedError(resource, id, format string, args ...interface{}) error {
+	return &Error{Kind: KindAborted, Resource: resource, ID: id, Message: fmt.Sprintf(format, args...)}
+}
+
+// UnauthenticatedError is a caller whose credentials for a resource can't be verified
+func UnauthenticatedError(resource, id, format string, args ...interface{}) error {
+	return &Error{Kind: KindUnauthenticated, Resource: resource, ID: id, Message: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is a call made with an idempotency key, it's kept so a
+// retry of the call gets the same response
+type IdempotencyRecord struct {
+	// Scope is the method and the caller the key belongs to, the same key
+	// can be used by other callers
+	Scope string
+	Key   string
+	// RequestHash tells a retry from another request sent with the same key
+	RequestHash string
+	// Response is the marshaled response and Revision the revision of the
+	// created resource, the response is nil while the call runs
+	Response  []byte
+	Revision  int64
+	CreatedAt time.Time
+	// LockedUntil ends the lease of the call, a call that's not completed by
+	// then is taken to have died and a retry can take the key over. It also
+	// tells the call holding the key from the one that took it over.
+	LockedUntil time.Time
+}
+
+// Completed reports whether the call is done and its response can be replayed
+func (r *IdempotencyRecord) Completed() bool {
+	return r.Response != nil
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Key is a musical key, the canonical form of the key of a project. Keys
+// are stored with the names of String, like "C", "F#m" or "Bb".
+type Key struct {
+	// Tonic is the pitch class of the key, 0 is C and 11 is B
+	Tonic int
+	Minor bool
+}
+
+// Names of the keys by tonic
+var (
+	majorKeyNames = [12]string{"C", "Db", "D", "Eb", "E", "F", "F#", "G", "Ab", "A", "Bb", "B"}
+	minorKeyNames = [12]string{"Cm", "C#m", "Dm", "Ebm", "Em", "Fm", "F#m", "Gm", "G#m", "Am", "Bbm", "Bm"}
+)
+
+// naturals are the pitch classes of the notes without accidentals
+var naturals = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+var (
+	// standardKeyPattern matches keys like "C", "F#", "Bbm" or "A minor"
+	standardKeyPattern = regexp.MustCompile(`^([A-Ga-g])([#b♯♭]?)\s*(m|min|minor|maj|major)?$`)
+	// wheelKeyPattern matches Camelot keys like "8A" and Open Key ones like "1m"
+	wheelKeyPattern = regexp.MustCompile(`^(1[0-2]|[1-9])([ABabdmDM])$`)
+)
+
+// ParseKey reads a key written like "Am", "A minor", in Camelot notation
+// like "8A", or in Open Key notation like "1m"
+func ParseKey(s string) (Key, bool) {
+	s = strings.TrimSpace(s)
+	if m := wheelKeyPattern.FindStringSubmatch(s); m != nil {
+		number, _ := strconv.Atoi(m[1])
+		switch strings.ToUpper(m[2]) {
+		case "A":
+			return camelotKey(number, true), true
+		case "B":
+			return camelotKey(number, false), true
+		case "M":
+			return camelotKey((number+6)%12+1, true), true
+		default:
+			return camelotKey((number+6)%12+1, false), true
+		}
+	}
+	m := standardKeyPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Key{}, false
+	}
+	tonic := naturals[strings.ToUpper(m[1])[0]]
+	switch m[2] {
+	case "#", "♯":
+		tonic++
+	case "b", "♭":
+		tonic--
+	}
+	minor := strings.HasPrefix(m[3], "m") && !strings.HasPrefix(m[3], "maj")
+	return Key{Tonic: (tonic + 12) % 12, Minor: minor}, true
+}
+
+// camelotKey is the key at a number of the Camelot wheel, numbers go up by
+// a fifth, 8A is A minor and 8B is C major
+func camelotKey(number int, minor bool) Key {
+	tonic := (number - 8) * 7
+	if minor {
+		tonic += 9
+	}
+	return Key{Tonic: (tonic%12 + 12) % 12, Minor: minor}
+}
+
+// String is the canonical name of the key
+func (k Key) String() string {
+	if k.Minor {
+		return minorKeyNames[k.Tonic]
+	}
+	return majorKeyNames[k.Tonic]
+}
+
+// camelotNumber is the number of the key on the Camelot wheel
+func (k Key) camelotNumber() int {
+	tonic := k.Tonic
+	if k.Minor {
+		tonic -= 9
+	}
+	// 7 is its own inverse modulo 12, so this undoes camelotKey
+	return ((tonic*7%12+12)%12+7)%12 + 1
+}
+
+// Camelot is the key in Camelot notation, like "8A" for A minor
+func (k Key) Camelot() string {
+	if k.Minor {
+		return strconv.Itoa(k.camelotNumber()) + "A"
+	}
+	return strconv.Itoa(k.camelotNumber()) + "B"
+}
+
+// OpenKey is the key in Open Key notation, like "1m" for A minor
+func (k Key) OpenKey() string {
+	number := (k.camelotNumber()+4)%12 + 1
+	if k.Minor {
+		return strconv.Itoa(number) + "m"
+	}
+	return strconv.Itoa(number) + "d"
+}
+
+// Compatible returns the keys that mix well with the key: itself, its
+// relative major or minor, and its neighbours on the Camelot wheel
+func (k Key) Compatible() []Key {
+	relative := Key{Tonic: (k.Tonic + 3) % 12, Minor: false}
+	if !k.Minor {
+		relative = Key{Tonic: (k.Tonic + 9) % 12, Minor: true}
+	}
+	return []Key{
+		k,
+		relative,
+		{Tonic: (k.Tonic + 7) % 12, Minor: k.Minor},
+		{Tonic: (k.Tonic + 5) % 12, Minor: k.Minor},
+	}
+}
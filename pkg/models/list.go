@@ -0,0 +1,14 @@
+package models
+
+// ListQuery is a page of a list. Items are sorted by id, or by a field then
+// by id, so a page can start right after the last item of the previous one.
+type ListQuery struct {
+	// After is the id the page starts after, it's empty for the first page
+	After string
+	// AfterValue is the sorted field of the item After, when the list is
+	// sorted by a field
+	AfterValue interface{}
+	// Offset skips items, it's only used by search, ranks aren't a keyset
+	Offset int
+	Limit  int
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+)
+
+// Project is a project as it's stored
+type Project struct {
+	*projects.ProjectInfo
+	// Revision counts the updates of the project, it's 1 once it's created
+	Revision int64
+	// CreatedAt and UpdatedAt are set by the store as it writes the project
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// LastVersionAt is when the latest version of the project was uploaded,
+	// it's nil until there's one
+	LastVersionAt *time.Time
+	// Template marks a project others are started from, the proto has no
+	// field for it so it's only set in the database
+	Template bool
+}
+
+// ProjectFields are the stored metadata fields of a project, in the order
+// updates write them. They're named like the proto fields and the columns.
+var ProjectFields = []string{"name", "description", "public", "bpm", "key", "genre", "daw"}
+
+// ProjectValue returns the value stored for a metadata field of a project
+func ProjectValue(meta *projects.ProjectMeta, field string) interface{} {
+	switch field {
+	case "name":
+		return meta.GetName()
+	case "description":
+		return meta.GetDescription()
+	case "public":
+		return meta.GetPublic()
+	case "bpm":
+		return meta.GetBpm()
+	case "key":
+		return meta.GetKey()
+	case "genre":
+		return meta.GetGenre()
+	case "daw":
+		return meta.GetDaw().String()
+	}
+	return nil
+}
+
+// Fields projects can be sorted by, they're sorted by id when none is set.
+// Projects without versions come last when sorted by SortByLastVersionAt,
+// in either direction.
+const (
+	SortByName          = "name"
+	SortByBpm           = "bpm"
+	SortByCreatedAt     = "created_at"
+	SortByUpdatedAt     = "updated_at"
+	SortByLastVersionAt = "last_version_at"
+)
+
+// SortValue returns the value of the field a project is sorted by, it's nil
+// for the last version time of a project without versions
+func (p *Project) SortValue(field string) interface{} {
+	switch field {
+	case SortByCreatedAt:
+		return p.CreatedAt
+	case SortByUpdatedAt:
+		return p.UpdatedAt
+	case SortByLastVersionAt:
+		if p.LastVersionAt == nil {
+			return nil
+		}
+		return *p.LastVersionAt
+	}
+	return ProjectValue(p.GetMetadata(), field)
+}
+
+// ProjectQuery is a page of a filtered and sorted list of projects
+type ProjectQuery struct {
+	ListQuery
+	Filter ProjectFilter
+	// OrderBy is the field projects are sorted by, names are sorted without
+	// case. Projects with the same value are sorted by id.
+	OrderBy    string
+	Descending bool
+}
+
+// ProjectStream receives the projects of a list
+type ProjectStream interface {
+	Send(*Project) error
+}
+
+// ProjectFilter narrows a list of projects, its zero value lists them all
+type ProjectFilter struct {
+	DAW *projects.DAW
+	// Genre is matched without case
+	Genre string
+	// Keys are canonical key names, a project in any of them passes
+	Keys []string
+	// MinBpm and MaxBpm are inclusive, zero doesn't bound the bpm
+	MinBpm int32
+	MaxBpm int32
+	// BpmRanges are inclusive, a project in any of them passes
+	BpmRanges []BpmRange
+	Public    *bool
+	Template  *bool
+	// Tags are lower case, a project passes with all of them
+	Tags []string
+	// CollectionID is a collection the project has to be in
+	CollectionID string
+	// ProjectID only lists one project, like the one of a share link
+	ProjectID string
+	// ExcludeID leaves a project out, like the one others are matched with
+	ExcludeID string
+	// Created, Updated and LastVersion bound the timestamps of a project, a
+	// project without versions is left out by a LastVersion bound
+	Created     TimeRange
+	Updated     TimeRange
+	LastVersion TimeRange
+}
+
+// BpmRange is an inclusive range of tempos
+type BpmRange struct {
+	Min int32
+	Max int32
+}
+
+// TimeRange is an inclusive range of times, a zero time doesn't bound it
+type TimeRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsZero reports whether the range is unbounded
+func (r TimeRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+// Contains reports whether a time is in the range, a nil one never is
+// unless the range is unbounded
+func (r TimeRange) Contains(t *time.Time) bool {
+	if r.IsZero() {
+		return true
+	}
+	return t != nil && !t.Before(r.From) && (r.To.IsZero() || !t.After(r.To))
+}
+
+// Match reports whether a project passes the fields of the filter about
+// the project itself, tags and collections are kept apart
+func (f *ProjectFilter) Match(project *Project) bool {
+	meta := project.GetMetadata()
+	switch {
+	case f.DAW != nil && meta.GetDaw() != *f.DAW:
+		return false
+	case f.Genre != "" && !strings.EqualFold(meta.GetGenre(), f.Genre):
+		return false
+	case len(f.Keys) > 0 && !containsString(f.Keys, meta.GetKey()):
+		return false
+	case f.MinBpm != 0 && meta.GetBpm() < f.MinBpm:
+		return false
+	case f.MaxBpm != 0 && meta.GetBpm() > f.MaxBpm:
+		return false
+	case len(f.BpmRanges) > 0 && !inRanges(f.BpmRanges, meta.GetBpm()):
+		return false
+	case f.Public != nil && meta.GetPublic() != *f.Public:
+		return false
+	case f.Template != nil && project.Template != *f.Template:
+		return false
+	case f.ProjectID != "" && project.GetId().GetId() != f.ProjectID:
+		return false
+	case f.ExcludeID != "" && project.GetId().GetId() == f.ExcludeID:
+		return false
+	case !f.Created.Contains(&project.CreatedAt):
+		return false
+	case !f.Updated.Contains(&project.UpdatedAt):
+		return false
+	case !f.LastVersion.Contains(project.LastVersionAt):
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func inRanges(ranges []BpmRange, bpm int32) bool {
+	for _, r := range ranges {
+		if bpm >= r.Min && bpm <= r.Max {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"html"
+	"strings"
+)
+
+// Kinds of search hits
+const (
+	HitProject = "project"
+	HitVersion = "version"
+)
+
+// Stores mark the matched words of a field between these, they're control
+// characters so the text of the field can't be taken for markup
+const (
+	MatchStart = "\x02"
+	MatchStop  = "\x03"
+)
+
+// Snippets are HTML, the matched words are between these and the rest of
+// the text is escaped
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// SearchQuery is a page of the projects and versions matching a text, best
+// matches first
+type SearchQuery struct {
+	ListQuery
+	Text string
+	// ProjectID limits the hits to a project and its versions, and VersionID
+	// limits the versions to one. They're set for share links.
+	ProjectID string
+	VersionID string
+}
+
+// SearchStream receives the hits of a search
+type SearchStream interface {
+	Send(*SearchHit) error
+}
+
+// SearchHit is a project or a version matching a search
+type SearchHit struct {
+	Kind string
+	ID   string
+	// ProjectID is the project of a version, or the id of a project
+	ProjectID string
+	// Rank orders the hits, higher is better. Ranks only compare hits of
+	// the same search.
+	Rank float64
+	// Snippets are the matched fields, with the matched words highlighted
+	Snippets map[string]string
+}
+
+// AddSnippet keeps the snippet of a field if it marks a match, the text is
+// escaped so only the highlights are markup
+func (h *SearchHit) AddSnippet(field, marked string) {
+	if !strings.Contains(marked, MatchStart) {
+		return
+	}
+	if h.Snippets == nil {
+		h.Snippets = map[string]string{}
+	}
+	h.Snippets[field] = highlight(marked)
+}
+
+// highlight escapes a marked text and turns its marks into highlights, the
+// marks that don't pair up, like ones in the text itself, are dropped
+func highlight(marked string) string {
+	var snippet strings.Builder
+	open := false
+	for {
+		i := strings.IndexAny(marked, MatchStart+MatchStop)
+		if i < 0 {
+			snippet.WriteString(html.EscapeString(marked))
+			break
+		}
+		snippet.WriteString(html.EscapeString(marked[:i]))
+		switch mark := marked[i : i+1]; {
+		case mark == MatchStart && !open:
+			snippet.WriteString(HighlightStart)
+			open = true
+		case mark == MatchStop && open:
+			snippet.WriteString(HighlightStop)
+			open = false
+		}
+		marked = marked[i+1:]
+	}
+	if open {
+		snippet.WriteString(HighlightStop)
+	}
+	return snippet.String()
+}
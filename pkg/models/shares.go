@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ShareLink grants read-only access to a project, or to a single version
+// of it, to anyone who knows the token
+type ShareLink struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id"`
+	// VersionID is empty when the whole project is shared
+	VersionID string `json:"version_id,omitempty"`
+	// Token is only known when the link is created, the database keeps its hash
+	Token     string `json:"token,omitempty"`
+	TokenHash string `json:"-"`
+	// Password is only set by the caller on creation, the database keeps its hash
+	Password     string     `json:"password,omitempty"`
+	PasswordHash string     `json:"-"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	// MaxDownloads is zero when downloads are not limited
+	MaxDownloads int32     `json:"max_downloads"`
+	Downloads    int32     `json:"downloads"`
+	Revoked      bool      `json:"revoked"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Expired reports whether the link can't be used anymore at the given time
+func (l *ShareLink) Expired(now time.Time) bool {
+	return l.Revoked || l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// Exhausted reports whether the link is out of downloads, it can still be
+// used to read the shared project
+func (l *ShareLink) Exhausted() bool {
+	return l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads
+}
@@ -0,0 +1,13 @@
+package models
+
+// ProjectTags are the tags of a project, they're lower case and sorted
+type ProjectTags struct {
+	ProjectID string   `json:"project_id"`
+	Tags      []string `json:"tags"`
+}
+
+// TagCount is a tag and the number of projects tagged with it
+type TagCount struct {
+	Tag      string `json:"tag"`
+	Projects int32  `json:"projects"`
+}
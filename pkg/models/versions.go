@@ -0,0 +1,62 @@
+package models
+
+import "github.com/droplez/droplez-go-proto/pkg/studio/versions"
+
+// Version is a version as it's stored
+type Version struct {
+	*versions.VersionInfo
+	// Revision counts the updates of the version, it's 1 once it's created
+	Revision int64
+}
+
+// VersionStream receives the versions of a list
+type VersionStream interface {
+	Send(*Version) error
+}
+
+// VersionFields are the stored metadata fields of a version, in the order
+// updates write them. They're named like the proto fields and the columns.
+var VersionFields = []string{"version", "project_id", "object_name", "message", "uploaded_at"}
+
+// VersionValue returns the value stored for a metadata field of a version
+func VersionValue(meta *versions.VersionMeta, field string) interface{} {
+	switch field {
+	case "version":
+		return meta.GetVersion()
+	case "project_id":
+		return meta.GetProjectId()
+	case "object_name":
+		return meta.GetObjectName()
+	case "message":
+		return meta.GetMessage()
+	case "uploaded_at":
+		return meta.GetUploadedAt().AsTime()
+	}
+	return nil
+}
+
+// VersionQuery is a page of a filtered list of versions, sorted by id
+type VersionQuery struct {
+	ListQuery
+	Filter VersionFilter
+}
+
+// VersionFilter narrows a list of versions, its zero value lists them all
+type VersionFilter struct {
+	// ProjectID only lists the versions of a project, like the one of a
+	// share link
+	ProjectID string
+	// VersionID only lists one version
+	VersionID string
+}
+
+// Match reports whether a version passes the filter
+func (f *VersionFilter) Match(version *Version) bool {
+	switch {
+	case f.ProjectID != "" && version.GetMetadata().GetProjectId() != f.ProjectID:
+		return false
+	case f.VersionID != "" && version.GetId().GetId() != f.VersionID:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CollectionRepo struct {
+	Pool *pgxpool.Pool
+}
+
+// CreateCollection inserts the collection and its projects in a transaction
+func (r CollectionRepo) CreateCollection(ctx context.Context, collection *models.Collection) error {
+	const sql = "INSERT INTO collections (id, name, description, created_at) VALUES ($1, $2, $3, $4)"
+
+	return r.Pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		_, err := exec(ctx, tx, "CollectionRepo.CreateCollection", sql,
+			collection.ID, collection.Name,
+			collection.Description, collection.CreatedAt,
+		)
+		if err != nil {
+			if violated, primaryKey := uniqueViolation(err); violated {
+				if primaryKey {
+					return errCollectionExists(collection.ID)
+				}
+				return errCollectionNameTaken(collection)
+			}
+			return err
+		}
+		return insertCollectionProjects(ctx, tx, collection)
+	})
+}
+
+// UpdateCollection rewrites the collection and replaces its projects in a
+// transaction
+func (r CollectionRepo) UpdateCollection(ctx context.Context, collection *models.Collection) error {
+	const sql = "UPDATE collections SET name = $1, description = $2 WHERE id = $3"
+	const clear = "DELETE FROM collection_projects WHERE collection_id = $1"
+
+	return r.Pool.BeginFunc(ctx, func(tx pgx.Tx) error {
+		tag, err := exec(ctx, tx, "CollectionRepo.UpdateCollection", sql,
+			collection.Name, collection.Description,
+			collection.ID,
+		)
+		if err != nil {
+			if violated, _ := uniqueViolation(err); violated {
+				return errCollectionNameTaken(collection)
+			}
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return errCollectionNotFoundByID(collection.ID)
+		}
+		if _, err := exec(ctx, tx, "CollectionRepo.ClearCollectionProjects", clear, collection.ID); err != nil {
+			return err
+		}
+		return insertCollectionProjects(ctx, tx, collection)
+	})
+}
+
+// insertCollectionProjects numbers the projects of a collection in their order
+func insertCollectionProjects(ctx context.Context, q querier, collection *models.Collection) error {
+	const sql = `INSERT INTO collection_projects (collection_id, project_id, position)
+								SELECT $1, project_id::uuid, position FROM unnest($2::text[]) WITH ORDINALITY AS members (project_id, position)`
+
+	_, err := exec(ctx, q, "CollectionRepo.InsertCollectionProjects", sql, collection.ID, collection.ProjectIDs)
+	return err
+}
+
+func (r CollectionRepo) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	const sql = "SELECT id, name, description, created_at FROM collections WHERE id = $1"
+	const members = "SELECT project_id FROM collection_projects WHERE collection_id = $1 ORDER BY position"
+
+	collection := &models.Collection{ProjectIDs: []string{}}
+	err := queryRow(ctx, r.Pool, "CollectionRepo.GetCollection", sql, id).Scan(
+		&collection.ID, &collection.Name,
+		&collection.Description, &collection.CreatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, errCollectionNotFoundByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query(ctx, r.Pool, "CollectionRepo.GetCollectionProjects", members, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var projectID string
+		if err := rows.Scan(&projectID); err != nil {
+			return nil, err
+		}
+		collection.ProjectIDs = append(collection.ProjectIDs, projectID)
+	}
+	return collection, rows.Err()
+}
+
+// ListCollections reads the collections, then the projects of them all
+func (r CollectionRepo) ListCollections(ctx context.Context) ([]*models.Collection, error) {
+	const sql = "SELECT id, name, description, created_at FROM collections ORDER BY name, id"
+	const members = "SELECT collection_id, project_id FROM collection_projects ORDER BY collection_id, position"
+
+	rows, err := query(ctx, r.Pool, "CollectionRepo.ListCollections", sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	collections := []*models.Collection{}
+	byID := map[string]*models.Collection{}
+	for rows.Next() {
+		collection := &models.Collection{ProjectIDs: []string{}}
+		err := rows.Scan(
+			&collection.ID, &collection.Name,
+			&collection.Description, &collection.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+		byID[collection.ID] = collection
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = query(ctx, r.Pool, "CollectionRepo.ListCollectionProjects", members)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID, projectID string
+		if err := rows.Scan(&collectionID, &projectID); err != nil {
+			return nil, err
+		}
+		// A collection created between the two reads isn't listed
+		if collection, ok := byID[collectionID]; ok {
+			collection.ProjectIDs = append(collection.ProjectIDs, projectID)
+		}
+	}
+	return collections, rows.Err()
+}
+
+func (r CollectionRepo) DeleteCollection(ctx context.Context, id string) error {
+	const sql = "DELETE FROM collections WHERE id = $1"
+
+	tag, err := exec(ctx, r.Pool, "CollectionRepo.DeleteCollection", sql, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errCollectionNotFoundByID(id)
+	}
+	return nil
+}
+
+// Local errors
+var (
+	errCollectionNotFoundByID = func(id string) error {
+		return models.NotFoundError("collection", id)
+	}
+	errCollectionExists = func(id string) error {
+		return models.ExistsError("collection", id)
+	}
+	errCollectionNameTaken = func(collection *models.Collection) error {
+		return models.ConflictError("collection", collection.ID, "a collection named %q already exists", collection.Name)
+	}
+)
@@ -0,0 +1,19 @@
+package repo
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgerrcode"
+)
+
+// uniqueViolation reports whether a statement failed on a unique constraint,
+// and whether that constraint is the primary key
+func uniqueViolation(err error) (violated, primaryKey bool) {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return false, false
+	}
+	return true, strings.HasSuffix(pgErr.ConstraintName, "_pkey")
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type IdempotencyRepo struct {
+	Pool *pgxpool.Pool
+}
+
+// ReserveIdempotencyKey inserts the record unless its key is taken, or takes
+// over a key whose lease ended. A key released between the insert and the
+// read is inserted again.
+func (r IdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, since time.Time) (*models.IdempotencyRecord, error) {
+	const expire = "DELETE FROM idempotency_keys WHERE created_at < $1"
+	const insert = `INSERT INTO idempotency_keys (scope, key, request_hash, created_at, locked_until)
+								VALUES ($1, $2, $3, $4, $5) ON CONFLICT (scope, key) DO UPDATE
+								SET request_hash = EXCLUDED.request_hash, created_at = EXCLUDED.created_at, locked_until = EXCLUDED.locked_until
+								WHERE idempotency_keys.response IS NULL AND idempotency_keys.locked_until <= EXCLUDED.created_at`
+	const sql = `SELECT request_hash, response, revision, created_at, locked_until
+								FROM idempotency_keys WHERE scope = $1 AND key = $2`
+
+	if _, err := exec(ctx, r.Pool, "IdempotencyRepo.ExpireIdempotencyKeys", expire, since.UTC()); err != nil {
+		return nil, err
+	}
+	for {
+		tag, err := exec(ctx, r.Pool, "IdempotencyRepo.ReserveIdempotencyKey", insert,
+			record.Scope, record.Key,
+			record.RequestHash, record.CreatedAt.UTC(),
+			record.LockedUntil.UTC(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if tag.RowsAffected() == 1 {
+			return nil, nil
+		}
+
+		previous := &models.IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+		err = queryRow(ctx, r.Pool, "IdempotencyRepo.GetIdempotencyKey", sql, record.Scope, record.Key).Scan(
+			&previous.RequestHash, &previous.Response,
+			&previous.Revision, &previous.CreatedAt,
+			&previous.LockedUntil,
+		)
+		if err == pgx.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return previous, nil
+	}
+}
+
+func (r IdempotencyRepo) CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	const sql = `UPDATE idempotency_keys SET response = $1, revision = $2
+								WHERE scope = $3 AND key = $4 AND locked_until = $5`
+
+	tag, err := exec(ctx, r.Pool, "IdempotencyRepo.CompleteIdempotencyKey", sql,
+		record.Response, record.Revision,
+		record.Scope, record.Key,
+		record.LockedUntil.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errIdempotencyKeyNotFound(record.Key)
+	}
+	return nil
+}
+
+func (r IdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	const sql = "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND locked_until = $3 AND response IS NULL"
+
+	_, err := exec(ctx, r.Pool, "IdempotencyRepo.ReleaseIdempotencyKey", sql, record.Scope, record.Key, record.LockedUntil.UTC())
+	return err
+}
+
+// Local errors
+var (
+	errIdempotencyKeyNotFound = func(key string) error {
+		return models.NotFoundError("idempotency key", key)
+	}
+)
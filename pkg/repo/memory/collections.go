@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// CollectionRepo keeps the collections of the projects of a ProjectRepo
+type CollectionRepo struct {
+	projects *ProjectRepo
+}
+
+func NewCollectionRepo(projects *ProjectRepo) *CollectionRepo {
+	return &CollectionRepo{projects: projects}
+}
+
+func (r *CollectionRepo) CreateCollection(ctx context.Context, collection *models.Collection) error {
+	r.projects.mu.Lock()
+	defer r.projects.mu.Unlock()
+
+	if _, ok := r.projects.collections[collection.ID]; ok {
+		return errCollectionExists(collection.ID)
+	}
+	if r.nameTaken(collection) {
+		return errCollectionNameTaken(collection)
+	}
+	r.projects.collections[collection.ID] = cloneCollection(collection)
+	return nil
+}
+
+func (r *CollectionRepo) UpdateCollection(ctx context.Context, collection *models.Collection) error {
+	r.projects.mu.Lock()
+	defer r.projects.mu.Unlock()
+
+	current, ok := r.projects.collections[collection.ID]
+	if !ok {
+		return errCollectionNotFoundByID(collection.ID)
+	}
+	if r.nameTaken(collection) {
+		return errCollectionNameTaken(collection)
+	}
+	updated := cloneCollection(collection)
+	updated.CreatedAt = current.CreatedAt
+	r.projects.collections[collection.ID] = updated
+	return nil
+}
+
+// nameTaken reports whether another collection has the name of collection
+func (r *CollectionRepo) nameTaken(collection *models.Collection) bool {
+	for id, other := range r.projects.collections {
+		if id != collection.ID && other.Name == collection.Name {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *CollectionRepo) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	r.projects.mu.RLock()
+	defer r.projects.mu.RUnlock()
+
+	collection, ok := r.projects.collections[id]
+	if !ok {
+		return nil, errCollectionNotFoundByID(id)
+	}
+	return cloneCollection(collection), nil
+}
+
+// ListCollections lists collections by name, like the postgres repo
+func (r *CollectionRepo) ListCollections(ctx context.Context) ([]*models.Collection, error) {
+	r.projects.mu.RLock()
+	defer r.projects.mu.RUnlock()
+
+	listed := []*models.Collection{}
+	for _, collection := range r.projects.collections {
+		listed = append(listed, cloneCollection(collection))
+	}
+	sort.Slice(listed, func(i, j int) bool {
+		if listed[i].Name != listed[j].Name {
+			return listed[i].Name < listed[j].Name
+		}
+		return listed[i].ID < listed[j].ID
+	})
+	return listed, nil
+}
+
+func (r *CollectionRepo) DeleteCollection(ctx context.Context, id string) error {
+	r.projects.mu.Lock()
+	defer r.projects.mu.Unlock()
+
+	if _, ok := r.projects.collections[id]; !ok {
+		return errCollectionNotFoundByID(id)
+	}
+	delete(r.projects.collections, id)
+	return nil
+}
+
+func cloneCollection(collection *models.Collection) *models.Collection {
+	clone := *collection
+	clone.ProjectIDs = append([]string{}, collection.ProjectIDs...)
+	return &clone
+}
+
+// Local errors
+var (
+	errCollectionNotFoundByID = func(id string) error {
+		return models.NotFoundError("collection", id)
+	}
+	errCollectionExists = func(id string) error {
+		return models.ExistsError("collection", id)
+	}
+	errCollectionNameTaken = func(collection *models.Collection) error {
+		return models.ConflictError("collection", collection.ID, "a collection named %q already exists", collection.Name)
+	}
+)
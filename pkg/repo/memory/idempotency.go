@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// IdempotencyRepo keeps idempotency keys in memory
+type IdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[idempotencyKey]*models.IdempotencyRecord
+}
+
+type idempotencyKey struct {
+	scope, key string
+}
+
+func NewIdempotencyRepo() *IdempotencyRepo {
+	return &IdempotencyRepo{records: map[idempotencyKey]*models.IdempotencyRecord{}}
+}
+
+func (r *IdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, since time.Time) (*models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, stored := range r.records {
+		if stored.CreatedAt.Before(since) {
+			delete(r.records, key)
+		}
+	}
+	key := idempotencyKey{record.Scope, record.Key}
+	if stored, ok := r.records[key]; ok && (stored.Completed() || record.CreatedAt.Before(stored.LockedUntil)) {
+		previous := *stored
+		return &previous, nil
+	}
+	// Only what the postgres repo stores is kept
+	stored := *record
+	stored.Response, stored.Revision = nil, 0
+	r.records[key] = &stored
+	return nil, nil
+}
+
+func (r *IdempotencyRepo) CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.records[idempotencyKey{record.Scope, record.Key}]
+	if !ok || !stored.LockedUntil.Equal(record.LockedUntil) {
+		return errIdempotencyKeyNotFound(record.Key)
+	}
+	stored.Response = append([]byte(nil), record.Response...)
+	stored.Revision = record.Revision
+	return nil
+}
+
+func (r *IdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := idempotencyKey{record.Scope, record.Key}
+	if stored, ok := r.records[key]; ok && !stored.Completed() && stored.LockedUntil.Equal(record.LockedUntil) {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+// Local errors
+var (
+	errIdempotencyKeyNotFound = func(key string) error {
+		return models.NotFoundError("idempotency key", key)
+	}
+)
@@ -0,0 +1,68 @@
+// Package memory keeps projects, versions, share links, tags, collections
+// and idempotency keys in memory, for demos and tests. Nothing survives a
+// restart.
+package memory
+
+import (
+	"sort"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// window returns the ids a LIMIT count OFFSET offset query would return
+func window(ids []string, count, offset int) []string {
+	if offset >= len(ids) || count <= 0 {
+		return nil
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	end := offset + count
+	if end > len(ids) {
+		end = len(ids)
+	}
+	return ids[offset:end]
+}
+
+// page returns the ids a keyset paginated query would return, ids are sorted
+func page(ids []string, list *models.ListQuery) []string {
+	start := sort.Search(len(ids), func(i int) bool { return ids[i] > list.After })
+	return window(ids[start:], list.Limit, list.Offset)
+}
+
+// insert adds an id to sorted ids
+func insert(ids []string, id string) []string {
+	i := sort.SearchStrings(ids, id)
+	ids = append(ids, "")
+	copy(ids[i+1:], ids[i:])
+	ids[i] = id
+	return ids
+}
+
+func remove(ids []string, id string) []string {
+	for i := range ids {
+		if ids[i] == id {
+			return append(ids[:i:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+// setFields copies the given fields of src onto dst, like an update writing
+// only those columns. Fields are named like the proto fields.
+func setFields(dst, src proto.Message, fields []string) {
+	to, from := dst.ProtoReflect(), src.ProtoReflect()
+	for _, field := range fields {
+		fd := to.Descriptor().Fields().ByName(protoreflect.Name(field))
+		if fd == nil {
+			continue
+		}
+		if from.Has(fd) {
+			to.Set(fd, from.Get(fd))
+		} else {
+			to.Clear(fd)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/droplez/droplez-studio/pkg/repo/memory"
+	"github.com/droplez/droplez-studio/pkg/repo/repotest"
+	"github.com/droplez/droplez-studio/pkg/service"
+)
+
+func TestProjectRepo(t *testing.T) {
+	repotest.RunProjectStore(t, func(t *testing.T) service.ProjectStore {
+		return memory.NewProjectRepo()
+	})
+}
+
+func TestVersionRepo(t *testing.T) {
+	repotest.RunVersionStore(t, func(t *testing.T) service.VersionStore {
+		return memory.NewVersionRepo(nil)
+	})
+}
+
+func TestShareLinkRepo(t *testing.T) {
+	repotest.RunShareLinkStore(t, func(t *testing.T) service.ShareLinkStore {
+		return memory.NewShareLinkRepo()
+	})
+}
+
+func TestIdempotencyRepo(t *testing.T) {
+	repotest.RunIdempotencyStore(t, func(t *testing.T) service.IdempotencyStore {
+		return memory.NewIdempotencyRepo()
+	})
+}
+
+func TestSearchRepo(t *testing.T) {
+	repotest.RunSearchStore(t, func(t *testing.T) repotest.SearchStores {
+		projects := memory.NewProjectRepo()
+		versions := memory.NewVersionRepo(projects)
+		return repotest.SearchStores{Projects: projects, Versions: versions, Search: memory.NewSearchRepo(projects, versions)}
+	})
+}
+
+func TestTagRepo(t *testing.T) {
+	repotest.RunTagStore(t, func(t *testing.T) repotest.TagStores {
+		projects := memory.NewProjectRepo()
+		return repotest.TagStores{Projects: projects, Tags: memory.NewTagRepo(projects)}
+	})
+}
+
+func TestCollectionRepo(t *testing.T) {
+	repotest.RunCollectionStore(t, func(t *testing.T) repotest.CollectionStores {
+		projects := memory.NewProjectRepo()
+		return repotest.CollectionStores{Projects: projects, Collections: memory.NewCollectionRepo(projects)}
+	})
+}
+
+func TestProjectActivity(t *testing.T) {
+	repotest.RunProjectActivity(t, func(t *testing.T) repotest.ActivityStores {
+		projects := memory.NewProjectRepo()
+		return repotest.ActivityStores{Projects: projects, Versions: memory.NewVersionRepo(projects)}
+	})
+}
@@ -0,0 +1,269 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProjectRepo keeps projects in memory, sorted by id like postgres lists them.
+// Tags and collections are kept with them, so projects can be filtered by
+// them and deleting a project drops them like the foreign keys do.
+type ProjectRepo struct {
+	mu          sync.RWMutex
+	projects    map[string]*models.Project
+	order       []string
+	tags        map[string][]string
+	collections map[string]*models.Collection
+}
+
+func NewProjectRepo() *ProjectRepo {
+	return &ProjectRepo{
+		projects:    map[string]*models.Project{},
+		tags:        map[string][]string{},
+		collections: map[string]*models.Collection{},
+	}
+}
+
+func (r *ProjectRepo) CreateProject(ctx context.Context, project *projects.ProjectInfo, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := project.GetId().GetId()
+	if _, ok := r.projects[id]; ok {
+		return errProjectExists(id)
+	}
+	r.projects[id] = &models.Project{
+		ProjectInfo: proto.Clone(project).(*projects.ProjectInfo),
+		Revision:    1,
+		CreatedAt:   now.UTC(),
+		UpdatedAt:   now.UTC(),
+	}
+	r.order = insert(r.order, id)
+	return nil
+}
+
+func (r *ProjectRepo) UpdateProject(ctx context.Context, project *projects.ProjectInfo, revision int64, fields []string, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := project.GetId().GetId()
+	current, ok := r.projects[id]
+	if !ok {
+		return 0, errProjectNotFoundByID(id)
+	}
+	if current.Revision != revision {
+		return 0, errProjectRevision(id, current.Revision)
+	}
+	updated := cloneProject(current)
+	if updated.Metadata == nil {
+		updated.Metadata = &projects.ProjectMeta{}
+	}
+	setFields(updated.Metadata, proto.Clone(project.GetMetadata()), fields)
+	updated.Revision = revision + 1
+	updated.UpdatedAt = now.UTC()
+	r.projects[id] = updated
+	return updated.Revision, nil
+}
+
+func (r *ProjectRepo) GetProject(ctx context.Context, projectID *projects.ProjectId) (*models.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	project, ok := r.projects[projectID.GetId()]
+	if !ok {
+		return nil, errProjectNotFoundByID(projectID.GetId())
+	}
+	return cloneProject(project), nil
+}
+
+func (r *ProjectRepo) MissingProjects(ctx context.Context, ids []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	missing := []string{}
+	for _, id := range ids {
+		if _, ok := r.projects[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+func (r *ProjectRepo) DeleteProject(ctx context.Context, projectID *projects.ProjectId) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := projectID.GetId()
+	if _, ok := r.projects[id]; !ok {
+		return errProjectNotFoundByID(id)
+	}
+	delete(r.projects, id)
+	r.order = remove(r.order, id)
+	delete(r.tags, id)
+	for _, collection := range r.collections {
+		collection.ProjectIDs = remove(collection.ProjectIDs, id)
+	}
+	return nil
+}
+
+// ListProjects pages like the postgres repo: the projects passing the filter
+// of the query, sorted by its field then by id, after its project
+func (r *ProjectRepo) ListProjects(ctx context.Context, stream models.ProjectStream, list *models.ProjectQuery) error {
+	r.mu.RLock()
+	var matched []string
+	var keys []sortKey
+	for _, id := range r.order {
+		project := r.projects[id]
+		if list.Filter.Match(project) && r.organized(id, &list.Filter) {
+			matched = append(matched, id)
+			keys = append(keys, projectSortKey(list, project))
+		}
+	}
+	sort.Sort(byKey{ids: matched, keys: keys, descending: list.Descending})
+
+	after := sortKeyOf(list.OrderBy, list.AfterValue, list.After)
+	start := sort.Search(len(keys), func(i int) bool {
+		return list.After == "" || keys[i].after(after, list.Descending)
+	})
+	var listed []*models.Project
+	for _, id := range window(matched[start:], list.Limit, list.Offset) {
+		listed = append(listed, cloneProject(r.projects[id]))
+	}
+	r.mu.RUnlock()
+
+	// Sending happens without the lock, a slow client mustn't block writers
+	for _, project := range listed {
+		if err := stream.Send(project); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// organized reports whether a project has the tags and is in the collection
+// of a filter
+func (r *ProjectRepo) organized(id string, filter *models.ProjectFilter) bool {
+	for _, tag := range filter.Tags {
+		if !containsString(r.tags[id], tag) {
+			return false
+		}
+	}
+	if filter.CollectionID != "" {
+		collection, ok := r.collections[filter.CollectionID]
+		return ok && containsString(collection.ProjectIDs, id)
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKey is what a project is sorted by, like the sort expressions of the
+// postgres repo: names without case, bpms or times, then ids. A missing
+// time is null, it's sorted last in either direction.
+type sortKey struct {
+	null   bool
+	text   string
+	number int64
+	id     string
+}
+
+func sortKeyOf(orderBy string, value interface{}, id string) sortKey {
+	key := sortKey{id: id}
+	switch orderBy {
+	case models.SortByName:
+		name, _ := value.(string)
+		key.text = strings.ToLower(name)
+	case models.SortByBpm:
+		bpm, _ := value.(int32)
+		key.number = int64(bpm)
+	case models.SortByCreatedAt, models.SortByUpdatedAt:
+		at, _ := value.(time.Time)
+		key.number = at.UnixNano()
+	case models.SortByLastVersionAt:
+		at, ok := value.(time.Time)
+		key.null, key.number = !ok, at.UnixNano()
+	}
+	return key
+}
+
+func projectSortKey(list *models.ProjectQuery, project *models.Project) sortKey {
+	var value interface{}
+	if list.OrderBy != "" {
+		value = project.SortValue(list.OrderBy)
+	}
+	return sortKeyOf(list.OrderBy, value, project.GetId().GetId())
+}
+
+// after reports whether k is sorted after other
+func (k sortKey) after(other sortKey, descending bool) bool {
+	if k == other {
+		return false
+	}
+	if k.null != other.null {
+		return k.null
+	}
+	greater := k.text > other.text ||
+		k.text == other.text && (k.number > other.number ||
+			k.number == other.number && k.id > other.id)
+	return greater != descending
+}
+
+// byKey sorts the ids of projects by their keys
+type byKey struct {
+	ids        []string
+	keys       []sortKey
+	descending bool
+}
+
+func (b byKey) Len() int           { return len(b.keys) }
+func (b byKey) Less(i, j int) bool { return b.keys[j].after(b.keys[i], b.descending) }
+func (b byKey) Swap(i, j int) {
+	b.ids[i], b.ids[j] = b.ids[j], b.ids[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// setLastVersionAt keeps the time of the latest version of a project, like
+// the triggers on the versions table. A missing project is left alone.
+func (r *ProjectRepo) setLastVersionAt(id string, at *time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if project, ok := r.projects[id]; ok {
+		updated := cloneProject(project)
+		updated.LastVersionAt = at
+		r.projects[id] = updated
+	}
+}
+
+func cloneProject(project *models.Project) *models.Project {
+	clone := *project
+	clone.ProjectInfo = proto.Clone(project.ProjectInfo).(*projects.ProjectInfo)
+	return &clone
+}
+
+// Local errors
+var (
+	errProjectNotFoundByID = func(id string) error {
+		return models.NotFoundError("project", id)
+	}
+	errProjectExists = func(id string) error {
+		return models.ExistsError("project", id)
+	}
+	errProjectRevision = func(id string, revision int64) error {
+		return models.StaleError("project", id, revision)
+	}
+)
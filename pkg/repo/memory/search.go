@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// SearchRepo searches the projects and versions of the memory repos. A word
+// of the text matches the words starting with it, and hits have to match
+// all of them.
+type SearchRepo struct {
+	projects *ProjectRepo
+	versions *VersionRepo
+}
+
+func NewSearchRepo(projects *ProjectRepo, versions *VersionRepo) *SearchRepo {
+	return &SearchRepo{projects: projects, versions: versions}
+}
+
+// searchField is a searched field, matches in it count weight to the rank.
+// Weights are the ones postgres gives to the weights of the search columns.
+type searchField struct {
+	name   string
+	text   string
+	weight float64
+}
+
+func (r *SearchRepo) Search(ctx context.Context, stream models.SearchStream, search *models.SearchQuery) error {
+	terms := strings.Fields(strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, search.Text)))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var hits []*models.SearchHit
+	r.projects.mu.RLock()
+	for _, id := range r.projects.order {
+		if search.ProjectID != "" && id != search.ProjectID {
+			continue
+		}
+		meta := r.projects.projects[id].GetMetadata()
+		hit := &models.SearchHit{Kind: models.HitProject, ID: id, ProjectID: id}
+		if matchHit(hit, terms, []searchField{
+			{"name", meta.GetName(), 1.0},
+			{"genre", meta.GetGenre(), 0.4},
+			{"description", meta.GetDescription(), 0.2},
+		}) {
+			hits = append(hits, hit)
+		}
+	}
+	r.projects.mu.RUnlock()
+
+	r.versions.mu.RLock()
+	for _, id := range r.versions.order {
+		meta := r.versions.versions[id].GetMetadata()
+		if search.ProjectID != "" && meta.GetProjectId() != search.ProjectID ||
+			search.VersionID != "" && id != search.VersionID {
+			continue
+		}
+		hit := &models.SearchHit{Kind: models.HitVersion, ID: id, ProjectID: meta.GetProjectId()}
+		if matchHit(hit, terms, []searchField{{"message", meta.GetMessage(), 0.2}}) {
+			hits = append(hits, hit)
+		}
+	}
+	r.versions.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.ID < b.ID
+	})
+	if search.Offset >= len(hits) {
+		return nil
+	}
+	hits = hits[search.Offset:]
+	if len(hits) > search.Limit {
+		hits = hits[:search.Limit]
+	}
+	for _, hit := range hits {
+		if err := stream.Send(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchHit ranks and highlights the fields of a hit, it reports whether
+// every term matched one of them
+func matchHit(hit *models.SearchHit, terms []string, fields []searchField) bool {
+	matched := make([]bool, len(terms))
+	for _, field := range fields {
+		var snippet strings.Builder
+		last := 0
+		for _, span := range wordSpans(field.text) {
+			word := strings.ToLower(field.text[span[0]:span[1]])
+			match := false
+			for i, term := range terms {
+				if strings.HasPrefix(word, term) {
+					matched[i], match = true, true
+				}
+			}
+			if !match {
+				continue
+			}
+			hit.Rank += field.weight
+			snippet.WriteString(field.text[last:span[0]])
+			snippet.WriteString(models.MatchStart + field.text[span[0]:span[1]] + models.MatchStop)
+			last = span[1]
+		}
+		snippet.WriteString(field.text[last:])
+		hit.AddSnippet(field.name, snippet.String())
+	}
+	for _, ok := range matched {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// wordSpans returns the start and end of the words of a text
+func wordSpans(text string) [][2]int {
+	var spans [][2]int
+	start := -1
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(text)})
+	}
+	return spans
+}
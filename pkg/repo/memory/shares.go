@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// ShareLinkRepo keeps share links in memory
+type ShareLinkRepo struct {
+	mu    sync.Mutex
+	links map[string]*models.ShareLink
+}
+
+func NewShareLinkRepo() *ShareLinkRepo {
+	return &ShareLinkRepo{links: map[string]*models.ShareLink{}}
+}
+
+func (r *ShareLinkRepo) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.links[link.ID]; ok {
+		return errShareLinkExists(link.ID)
+	}
+	for _, other := range r.links {
+		if other.TokenHash == link.TokenHash {
+			return errShareLinkTokenExists(link.ID)
+		}
+	}
+	// Only what the postgres repo stores is kept
+	stored := *link
+	stored.Token, stored.Password = "", ""
+	stored.Downloads, stored.Revoked = 0, false
+	r.links[link.ID] = &stored
+	return nil
+}
+
+func (r *ShareLinkRepo) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return nil, errShareLinkNotFoundByID(id)
+	}
+	found := *link
+	return &found, nil
+}
+
+func (r *ShareLinkRepo) GetShareLinkByToken(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, link := range r.links {
+		if link.TokenHash == tokenHash {
+			found := *link
+			return &found, nil
+		}
+	}
+	return nil, errShareLinkNotFound
+}
+
+func (r *ShareLinkRepo) RevokeShareLink(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok {
+		return errShareLinkNotFoundByID(id)
+	}
+	link.Revoked = true
+	return nil
+}
+
+// RegisterShareLinkDownload counts a download, it fails once the link
+// is revoked, expired at the given time or out of downloads
+func (r *ShareLinkRepo) RegisterShareLinkDownload(ctx context.Context, id string, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	link, ok := r.links[id]
+	if !ok || link.Expired(now) || link.Exhausted() {
+		return errShareLinkExhausted(id)
+	}
+	link.Downloads++
+	return nil
+}
+
+// Local errors
+var (
+	errShareLinkNotFound = &models.Error{
+		Kind:     models.KindNotFound,
+		Resource: "share link",
+		Message:  "share link can not be found",
+	}
+	errShareLinkNotFoundByID = func(id string) error {
+		return models.NotFoundError("share link", id)
+	}
+	errShareLinkExists = func(id string) error {
+		return models.ExistsError("share link", id)
+	}
+	errShareLinkTokenExists = func(id string) error {
+		return models.ConflictError("share link", id, "share link with this token already exists")
+	}
+	errShareLinkExhausted = func(id string) error {
+		return models.PermissionError("share link", id, "share link is revoked, expired or out of downloads")
+	}
+)
@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// TagRepo keeps the tags of the projects of a ProjectRepo
+type TagRepo struct {
+	projects *ProjectRepo
+}
+
+func NewTagRepo(projects *ProjectRepo) *TagRepo {
+	return &TagRepo{projects: projects}
+}
+
+func (r *TagRepo) SetProjectTags(ctx context.Context, projectID string, tags []string) error {
+	r.projects.mu.Lock()
+	defer r.projects.mu.Unlock()
+
+	if _, ok := r.projects.projects[projectID]; !ok {
+		return errProjectNotFoundByID(projectID)
+	}
+	if len(tags) == 0 {
+		delete(r.projects.tags, projectID)
+		return nil
+	}
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	r.projects.tags[projectID] = sorted
+	return nil
+}
+
+func (r *TagRepo) GetProjectTags(ctx context.Context, projectID string) ([]string, error) {
+	r.projects.mu.RLock()
+	defer r.projects.mu.RUnlock()
+
+	return append([]string{}, r.projects.tags[projectID]...), nil
+}
+
+func (r *TagRepo) ListTags(ctx context.Context) ([]*models.TagCount, error) {
+	r.projects.mu.RLock()
+	defer r.projects.mu.RUnlock()
+
+	counts := map[string]int32{}
+	for _, tags := range r.projects.tags {
+		for _, tag := range tags {
+			counts[tag]++
+		}
+	}
+	listed := []*models.TagCount{}
+	for tag, projects := range counts {
+		listed = append(listed, &models.TagCount{Tag: tag, Projects: projects})
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Tag < listed[j].Tag })
+	return listed, nil
+}
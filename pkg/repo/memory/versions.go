@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"google.golang.org/protobuf/proto"
+)
+
+// VersionRepo keeps versions in memory, sorted by id like postgres lists them.
+// It keeps the last_version_at of the projects of a ProjectRepo, when it has one.
+type VersionRepo struct {
+	mu       sync.RWMutex
+	versions map[string]*models.Version
+	order    []string
+	projects *ProjectRepo
+}
+
+// NewVersionRepo returns an empty VersionRepo, projects may be nil
+func NewVersionRepo(projects *ProjectRepo) *VersionRepo {
+	return &VersionRepo{versions: map[string]*models.Version{}, projects: projects}
+}
+
+func (r *VersionRepo) CreateVersion(ctx context.Context, version *versions.VersionInfo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := version.GetId().GetId()
+	if _, ok := r.versions[id]; ok {
+		return errVersionExists(id)
+	}
+	if r.numberTaken(version) {
+		return errVersionNumberExists(version)
+	}
+	r.versions[id] = &models.Version{VersionInfo: proto.Clone(version).(*versions.VersionInfo), Revision: 1}
+	r.order = insert(r.order, id)
+	r.touch(version.GetMetadata().GetProjectId())
+	return nil
+}
+
+func (r *VersionRepo) UpdateVersion(ctx context.Context, version *versions.VersionInfo, revision int64, fields []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := version.GetId().GetId()
+	current, ok := r.versions[id]
+	if !ok {
+		return 0, errVersionNotFoundByID(id)
+	}
+	if current.Revision != revision {
+		return 0, errVersionRevision(id, current.Revision)
+	}
+	updated := cloneVersion(current)
+	if updated.Metadata == nil {
+		updated.Metadata = &versions.VersionMeta{}
+	}
+	setFields(updated.Metadata, proto.Clone(version.GetMetadata()), fields)
+	if r.numberTaken(updated.VersionInfo) {
+		return 0, errVersionNumberExists(updated.VersionInfo)
+	}
+	updated.Revision = revision + 1
+	r.versions[id] = updated
+	r.touch(current.GetMetadata().GetProjectId(), updated.GetMetadata().GetProjectId())
+	return updated.Revision, nil
+}
+
+func (r *VersionRepo) GetVersions(ctx context.Context, in *versions.VersionId) (*models.Version, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	version, ok := r.versions[in.GetId()]
+	if !ok {
+		return nil, errVersionNotFoundByID(in.GetId())
+	}
+	return cloneVersion(version), nil
+}
+
+// ListVersions pages like the postgres repo: the versions passing the filter
+// after the id of the query, sorted by id
+func (r *VersionRepo) ListVersions(ctx context.Context, stream models.VersionStream, list *models.VersionQuery) error {
+	r.mu.RLock()
+	var matched []string
+	for _, id := range r.order {
+		if list.Filter.Match(r.versions[id]) {
+			matched = append(matched, id)
+		}
+	}
+	var listed []*models.Version
+	for _, id := range page(matched, &list.ListQuery) {
+		listed = append(listed, cloneVersion(r.versions[id]))
+	}
+	r.mu.RUnlock()
+
+	for _, version := range listed {
+		if err := stream.Send(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numberTaken reports whether another version of the project has the same
+// number, like the unique (project_id, version) constraint
+func (r *VersionRepo) numberTaken(version *versions.VersionInfo) bool {
+	for id, other := range r.versions {
+		if id != version.GetId().GetId() &&
+			other.GetMetadata().GetProjectId() == version.GetMetadata().GetProjectId() &&
+			other.GetMetadata().GetVersion() == version.GetMetadata().GetVersion() {
+			return true
+		}
+	}
+	return false
+}
+
+// touch sets the last_version_at of projects whose versions changed, the
+// lock of the versions is held
+func (r *VersionRepo) touch(projectIDs ...string) {
+	if r.projects == nil {
+		return
+	}
+	for _, projectID := range projectIDs {
+		var last *time.Time
+		for _, version := range r.versions {
+			if version.GetMetadata().GetProjectId() != projectID {
+				continue
+			}
+			if uploadedAt := version.GetMetadata().GetUploadedAt().AsTime(); last == nil || uploadedAt.After(*last) {
+				last = &uploadedAt
+			}
+		}
+		r.projects.setLastVersionAt(projectID, last)
+	}
+}
+
+func cloneVersion(version *models.Version) *models.Version {
+	return &models.Version{VersionInfo: proto.Clone(version.VersionInfo).(*versions.VersionInfo), Revision: version.Revision}
+}
+
+// Local errors
+var (
+	errVersionNotFoundByID = func(id string) error {
+		return models.NotFoundError("version", id)
+	}
+	errVersionExists = func(id string) error {
+		return models.ExistsError("version", id)
+	}
+	errVersionRevision = func(id string, revision int64) error {
+		return models.StaleError("version", id, revision)
+	}
+	errVersionNumberExists = func(version *versions.VersionInfo) error {
+		return models.ConflictError("version", version.GetId().GetId(), "project %s already has a version %d",
+			version.GetMetadata().GetProjectId(), version.GetMetadata().GetVersion())
+	}
+)
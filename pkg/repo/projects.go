@@ -2,174 +2,199 @@ package repo
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
-	"github.com/droplez/droplez-studio/tools/logger"
-	"github.com/jackc/pgconn"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"google.golang.org/grpc/codes"
 )
 
 type ProjectRepo struct {
-	Pool *pgxpool.Conn
+	Pool *pgxpool.Pool
 }
 
-func (r ProjectRepo) CreateProject(ctx context.Context, project *projects.ProjectInfo) (code codes.Code, err error) {
+func (r ProjectRepo) CreateProject(ctx context.Context, project *projects.ProjectInfo, now time.Time) error {
 	const sql = `INSERT INTO projects 
-								(id, name, daw, description, public, bpm, key, genre) 
-								VALUES ($1, $2, $3, $4, $5, $6, $7, $8);`
+								(id, name, daw, description, public, bpm, key, genre, created_at, updated_at) 
+								VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9);`
 
-	var log = logger.GetGrpcLogger(ctx)
-	_, err = r.Pool.Exec(ctx, sql,
+	_, err := exec(ctx, r.Pool, "ProjectRepo.CreateProject", sql,
 		project.Id.Id, project.Metadata.Name,
 		project.Metadata.Daw.String(), project.Metadata.Description,
 		project.Metadata.Public, project.Metadata.Bpm,
 		project.Metadata.Key, project.Metadata.Genre,
+		now,
 	)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.UniqueViolation:
-				return codes.AlreadyExists, err
-			default:
-				log.Error(err)
-				return codes.Internal, err
-			}
+		if violated, _ := uniqueViolation(err); violated {
+			return errProjectExists(project.GetId().GetId())
 		}
-		log.Error(err)
-		return codes.Internal, err
+		return err
 	}
 
-	return codes.OK, nil
+	return nil
 }
 
-func (r ProjectRepo) UpdateProject(ctx context.Context, project *projects.ProjectInfo) (code codes.Code, err error) {
-	const sql = "UPDATE projects SET name=$2, description=$3, public=$4, bpm=$5, key=$6, genre=$7, daw=$8 WHERE id=$1 RETURNING *"
-
-	var log = logger.GetGrpcLogger(ctx)
+func (r ProjectRepo) UpdateProject(ctx context.Context, project *projects.ProjectInfo, revision int64, fields []string, now time.Time) (int64, error) {
+	// $1 is the id, $2 the revision and $3 the time of the update, the fields follow
+	set, args, err := setColumns(fields, models.ProjectFields, 4, func(field string) interface{} {
+		return models.ProjectValue(project.GetMetadata(), field)
+	})
+	if err != nil {
+		return 0, err
+	}
+	sql := fmt.Sprintf("UPDATE projects SET %s, revision=revision+1, updated_at=$3 WHERE id=$1 AND revision=$2 RETURNING revision", set)
 
-	_, err = r.Pool.Exec(ctx, sql,
-		project.Id, project.Metadata.Name,
-		project.Metadata.Description, project.Metadata.Public,
-		project.Metadata.Bpm, project.Metadata.Key,
-		project.Metadata.Daw.String(),
-	)
+	args = append([]interface{}{project.GetId().GetId(), revision, now}, args...)
+	err = queryRow(ctx, r.Pool, "ProjectRepo.UpdateProject", sql, args...).Scan(&revision)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.UniqueViolation:
-				return codes.AlreadyExists, err
-			default:
-				log.Error(err)
-				return codes.Internal, err
-			}
+		if err == pgx.ErrNoRows {
+			return 0, r.currentRevision(ctx, project.GetId().GetId())
 		}
+		return 0, err
 	}
 
-	return codes.OK, nil
+	return revision, nil
 }
 
-func (r ProjectRepo) GetProject(ctx context.Context, projectID *projects.ProjectId) (*projects.ProjectInfo, codes.Code, error) {
-	const sql = "SELECT name, description, public, bpm, key, genre, daw FROM projects WHERE id = $1"
+// currentRevision tells why an update didn't match a row, the project is
+// either gone or at another revision
+func (r ProjectRepo) currentRevision(ctx context.Context, id string) error {
+	const sql = "SELECT revision FROM projects WHERE id = $1"
+
+	var revision int64
+	err := queryRow(ctx, r.Pool, "ProjectRepo.UpdateProject", sql, id).Scan(&revision)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errProjectNotFoundByID(id)
+		}
+		return err
+	}
+	return errProjectRevision(id, revision)
+}
+
+func (r ProjectRepo) GetProject(ctx context.Context, projectID *projects.ProjectId) (*models.Project, error) {
+	const sql = `SELECT name, description, public, bpm, key, genre, daw, revision,
+								created_at, updated_at, last_version_at, template FROM projects WHERE id = $1`
 
-	var log = logger.GetGrpcLogger(ctx)
 	var projectMeta = &projects.ProjectMeta{}
 	var daw string
+	stored := &models.Project{}
 
-	err := r.Pool.QueryRow(ctx, sql, projectID.GetId()).Scan(
+	err := queryRow(ctx, r.Pool, "ProjectRepo.GetProject", sql, projectID.GetId()).Scan(
 		&projectMeta.Name, &projectMeta.Description, &projectMeta.Public,
 		&projectMeta.Bpm, &projectMeta.Key, &projectMeta.Genre,
-		&daw,
+		&daw, &stored.Revision,
+		&stored.CreatedAt, &stored.UpdatedAt, &stored.LastVersionAt,
+		&stored.Template,
 	)
 	projectMeta.Daw = projects.DAW(projects.DAW_value[daw])
 
 	project := &projects.ProjectInfo{
+		Id:       &projects.ProjectId{Id: projectID.GetId()},
 		Metadata: projectMeta,
 	}
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, codes.NotFound, errProjectNotFoundByID(projectID.GetId())
-		} else {
-			log.Error(err)
-			return nil, codes.Internal, err
+			return nil, errProjectNotFoundByID(projectID.GetId())
 		}
+		return nil, err
 	}
 
-	return project, codes.OK, nil
+	stored.ProjectInfo = project
+	return stored, nil
 }
 
-func (r ProjectRepo) DeleteProject(ctx context.Context, projectID *projects.ProjectId) (codes.Code, error) {
-	const sql = "DELETE FROM projects WHERE id = $1"
+func (r ProjectRepo) MissingProjects(ctx context.Context, ids []string) ([]string, error) {
+	const sql = `SELECT ids.id FROM unnest($1::text[]) WITH ORDINALITY AS ids (id, position)
+								WHERE NOT EXISTS (SELECT 1 FROM projects WHERE projects.id = ids.id::uuid) ORDER BY ids.position`
 
-	log := logger.GetGrpcLogger(ctx)
+	rows, err := query(ctx, r.Pool, "ProjectRepo.MissingProjects", sql, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	tag, err := r.Pool.Exec(ctx, sql, projectID.GetId())
-	if tag.RowsAffected() == 0 {
-		return codes.NotFound, errProjectNotFoundByID(projectID.GetId())
+	missing := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		missing = append(missing, id)
 	}
+	return missing, rows.Err()
+}
+
+func (r ProjectRepo) DeleteProject(ctx context.Context, projectID *projects.ProjectId) error {
+	const sql = "DELETE FROM projects WHERE id = $1"
+
+	tag, err := exec(ctx, r.Pool, "ProjectRepo.DeleteProject", sql, projectID.GetId())
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return codes.NotFound, errProjectNotFoundByID(projectID.GetId())
-		} else {
-			log.Error(err)
-			return codes.Internal, err
-		}
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errProjectNotFoundByID(projectID.GetId())
 	}
 
-	return codes.OK, nil
+	return nil
 }
 
-func (r ProjectRepo) ListProjects(ctx context.Context, stream projects.Projects_ListServer, opt *projects.ListOptions) (codes.Code, error) {
-	const sql = "SELECT id, name, description, public, bpm, key, genre, daw FROM projects LIMIT $1 OFFSET $2"
+func (r ProjectRepo) ListProjects(ctx context.Context, stream models.ProjectStream, list *models.ProjectQuery) error {
+	clause, args := projectClause(list)
+	sql := "SELECT id, name, description, public, bpm, key, genre, daw, revision, created_at, updated_at, last_version_at, template FROM projects" + clause
 	var (
-		log         = logger.GetGrpcLogger(ctx)
 		project     = &projects.ProjectInfo{}
 		projectMeta = &projects.ProjectMeta{}
 		projectID   = &projects.ProjectId{}
 		daw         string
 	)
 
-	rows, err := r.Pool.Query(ctx, sql, opt.GetPaging().GetCount(), opt.GetPaging().GetPage())
+	rows, err := query(ctx, r.Pool, "ProjectRepo.ListProjects", sql, args...)
 	if err != nil {
-		log.Error(err)
-		return codes.Internal, err
+		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
+		stored := &models.Project{}
 		err = rows.Scan(
 			&projectID.Id, &projectMeta.Name,
 			&projectMeta.Description, &projectMeta.Public,
 			&projectMeta.Bpm, &projectMeta.Key,
 			&projectMeta.Genre, &daw,
+			&stored.Revision, &stored.CreatedAt,
+			&stored.UpdatedAt, &stored.LastVersionAt,
+			&stored.Template,
 		)
 		projectMeta.Daw = projects.DAW(projects.DAW_value[daw])
 		if err != nil {
-			log.Error(err)
-			return codes.Internal, err
+			return err
 		}
 		project.Id = projectID
 		project.Metadata = projectMeta
-		if err := stream.Send(project); err != nil {
-			log.Error(err)
-			return codes.Internal, err
+		stored.ProjectInfo = project
+		if err := stream.Send(stored); err != nil {
+			return err
 		}
 	}
-	return codes.OK, nil
-
+	return rows.Err()
 }
 
-//Local errors
+// Local errors
 var (
 	errProjectNotFoundByID = func(id string) error {
-		return fmt.Errorf("project with this id can not be found: %s", id)
+		return models.NotFoundError("project", id)
+	}
+	errProjectExists = func(id string) error {
+		return models.ExistsError("project", id)
+	}
+	errProjectRevision = func(id string, revision int64) error {
+		return models.StaleError("project", id, revision)
 	}
 )
@@ -0,0 +1,111 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ActivityStores are a project store and the store of the versions its
+// last_version_at follows
+type ActivityStores struct {
+	Projects service.ProjectStore
+	Versions service.VersionStore
+}
+
+// RunProjectActivity checks that the last_version_at of projects follows
+// their versions like with the postgres repos
+func RunProjectActivity(t *testing.T, newStores func(t *testing.T) ActivityStores) {
+	t.Run("LastVersionAt", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift")
+		expectLastVersionAt(t, stores.Projects, ids[0], nil)
+
+		latest := newVersion(ids[0], 2)
+		expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, latest))
+		earlier := newVersion(ids[0], 1)
+		earlier.Metadata.UploadedAt = timestamppb.New(latest.GetMetadata().GetUploadedAt().AsTime().Add(-time.Hour))
+		expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, earlier))
+		expectLastVersionAt(t, stores.Projects, ids[0], latest.GetMetadata().GetUploadedAt())
+		expectLastVersionAt(t, stores.Projects, ids[1], nil)
+
+		// Moving the latest version moves the activity with it
+		latest.Metadata.ProjectId = ids[1]
+		_, err := stores.Versions.UpdateVersion(ctx, latest, 1, []string{"project_id"})
+		expectOK(t, "UpdateVersion", err)
+		expectLastVersionAt(t, stores.Projects, ids[0], earlier.GetMetadata().GetUploadedAt())
+		expectLastVersionAt(t, stores.Projects, ids[1], latest.GetMetadata().GetUploadedAt())
+	})
+
+	t.Run("ListStale", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift", "Sunday Morning")
+		stale := newVersion(ids[0], 1)
+		stale.Metadata.UploadedAt = timestamppb.New(uploadTime().Add(-30 * 24 * time.Hour))
+		expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, stale))
+		expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, newVersion(ids[1], 1)))
+
+		got := queryProjects(t, stores.Projects, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{LastVersion: models.TimeRange{To: uploadTime().Add(-7 * 24 * time.Hour)}},
+		})
+		if len(got) != 1 || got[0].GetId().GetId() != ids[0] {
+			t.Fatalf("ListProjects by last version: got %d projects, want only the stale one", len(got))
+		}
+		expectLastVersionAt(t, stores.Projects, got[0].GetId().GetId(), stale.GetMetadata().GetUploadedAt())
+	})
+
+	t.Run("ListByLastVersion", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "a", "b", "c", "d", "e", "f", "g")
+		for i, id := range ids[:4] {
+			version := newVersion(id, 1)
+			version.Metadata.UploadedAt = timestamppb.New(uploadTime().Add(-time.Duration(i%2) * time.Hour))
+			expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, version))
+		}
+
+		for _, descending := range []bool{false, true} {
+			// Pages of 2 cut through equal times and projects without versions
+			var listed []*models.Project
+			list := &models.ProjectQuery{ListQuery: models.ListQuery{Limit: 2}, OrderBy: models.SortByLastVersionAt, Descending: descending}
+			for {
+				got := queryProjects(t, stores.Projects, list)
+				listed = append(listed, got...)
+				if len(got) < list.Limit {
+					break
+				}
+				last := got[len(got)-1]
+				list.After, list.AfterValue = last.GetId().GetId(), last.SortValue(models.SortByLastVersionAt)
+			}
+			if len(listed) != len(ids) {
+				t.Fatalf("ListProjects by last version (descending %t): got %d projects over all pages, want %d", descending, len(listed), len(ids))
+			}
+			for i, project := range listed {
+				if (i < 4) != (project.LastVersionAt != nil) {
+					t.Fatalf("ListProjects by last version (descending %t): projects without versions aren't last", descending)
+				}
+				if i == 0 {
+					continue
+				}
+				if cmp := compareBy(models.SortByLastVersionAt, listed[i-1], project); descending && cmp < 0 || !descending && cmp > 0 {
+					t.Fatalf("ListProjects by last version (descending %t): %q is listed before %q", descending, listed[i-1].GetMetadata().GetName(), project.GetMetadata().GetName())
+				}
+			}
+		}
+	})
+}
+
+func expectLastVersionAt(t *testing.T, store service.ProjectStore, projectID string, want *timestamppb.Timestamp) {
+	t.Helper()
+	project, err := store.GetProject(context.Background(), &projects.ProjectId{Id: projectID})
+	expectOK(t, "GetProject", err)
+	got := project.LastVersionAt
+	if (got == nil) != (want == nil) || want != nil && !got.Equal(want.AsTime()) {
+		t.Fatalf("GetProject: got last_version_at %v, want %v", got, want.AsTime())
+	}
+}
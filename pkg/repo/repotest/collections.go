@@ -0,0 +1,178 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+)
+
+// CollectionStores are a collection store and the store of the collected
+// projects
+type CollectionStores struct {
+	Projects    service.ProjectStore
+	Collections service.CollectionStore
+}
+
+// RunCollectionStore checks that a CollectionStore behaves like the postgres repo
+func RunCollectionStore(t *testing.T, newStores func(t *testing.T) CollectionStores) {
+	t.Run("CreateGet", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		collection := newCollection("Live set", createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift")...)
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		got, err := stores.Collections.GetCollection(ctx, collection.ID)
+		expectOK(t, "GetCollection", err)
+		expectCollection(t, collection, got)
+	})
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		collection := newCollection("Live set")
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		duplicate := newCollection("Demos")
+		duplicate.ID = collection.ID
+		err := stores.Collections.CreateCollection(ctx, duplicate)
+		expectKind(t, "CreateCollection with an existing id", models.KindConflict, err)
+		err = stores.Collections.CreateCollection(ctx, newCollection("Live set"))
+		expectKind(t, "CreateCollection with an existing name", models.KindConflict, err)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		_, err := stores.Collections.GetCollection(ctx, uuid.New().String())
+		expectKind(t, "GetCollection", models.KindNotFound, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift", "Sunday Morning")
+		collection := newCollection("Live set", ids[:2]...)
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		collection.Name, collection.Description = "Closing set", "the last hour"
+		collection.ProjectIDs = []string{ids[2], ids[0]}
+		expectOK(t, "UpdateCollection", stores.Collections.UpdateCollection(ctx, collection))
+		got, err := stores.Collections.GetCollection(ctx, collection.ID)
+		expectOK(t, "GetCollection", err)
+		expectCollection(t, collection, got)
+
+		taken := newCollection("Demos")
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, taken))
+		taken.Name = collection.Name
+		err = stores.Collections.UpdateCollection(ctx, taken)
+		expectKind(t, "UpdateCollection to a taken name", models.KindConflict, err)
+		err = stores.Collections.UpdateCollection(ctx, newCollection("Missing"))
+		expectKind(t, "UpdateCollection of a missing collection", models.KindNotFound, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift")
+		live := newCollection("Live set", ids[1], ids[0])
+		demos := newCollection("Demos", ids[0])
+		empty := newCollection("Empty")
+		for _, collection := range []*models.Collection{live, demos, empty} {
+			expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+		}
+
+		got, err := stores.Collections.ListCollections(ctx)
+		expectOK(t, "ListCollections", err)
+		if len(got) != 3 {
+			t.Fatalf("ListCollections: got %d collections, want 3", len(got))
+		}
+		for i, want := range []*models.Collection{demos, empty, live} {
+			expectCollection(t, want, got[i])
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		collection := newCollection("Live set", createProjects(t, stores.Projects, "Tape Loops")...)
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		expectOK(t, "DeleteCollection", stores.Collections.DeleteCollection(ctx, collection.ID))
+		_, err := stores.Collections.GetCollection(ctx, collection.ID)
+		expectKind(t, "GetCollection of a deleted collection", models.KindNotFound, err)
+		err = stores.Collections.DeleteCollection(ctx, collection.ID)
+		expectKind(t, "DeleteCollection of a deleted collection", models.KindNotFound, err)
+		listed := queryProjects(t, stores.Projects, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{CollectionID: collection.ID},
+		})
+		if len(listed) != 0 {
+			t.Fatalf("ListProjects: got %d projects of a deleted collection", len(listed))
+		}
+	})
+
+	t.Run("DeleteProject", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift")
+		collection := newCollection("Live set", ids...)
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		expectOK(t, "DeleteProject", stores.Projects.DeleteProject(ctx, &projects.ProjectId{Id: ids[0]}))
+		got, err := stores.Collections.GetCollection(ctx, collection.ID)
+		expectOK(t, "GetCollection", err)
+		collection.ProjectIDs = ids[1:]
+		expectCollection(t, collection, got)
+	})
+
+	t.Run("ListProjects", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		ids := createProjects(t, stores.Projects, "Tape Loops", "Nebula Drift", "Sunday Morning")
+		collection := newCollection("Live set", ids[2], ids[0])
+		expectOK(t, "CreateCollection", stores.Collections.CreateCollection(ctx, collection))
+
+		listed := queryProjects(t, stores.Projects, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{CollectionID: collection.ID},
+		})
+		got := map[string]bool{}
+		for _, project := range listed {
+			got[project.GetId().GetId()] = true
+		}
+		if want := map[string]bool{ids[0]: true, ids[2]: true}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListProjects: got %v of the collection, want %v", got, want)
+		}
+	})
+}
+
+// createProjects creates projects with the given names and returns their ids
+func createProjects(t *testing.T, store service.ProjectStore, names ...string) []string {
+	t.Helper()
+	var ids []string
+	for _, name := range names {
+		project := newProject(name)
+		expectOK(t, "CreateProject", store.CreateProject(context.Background(), project, time.Now().UTC()))
+		ids = append(ids, project.GetId().GetId())
+	}
+	return ids
+}
+
+func newCollection(name string, projectIDs ...string) *models.Collection {
+	return &models.Collection{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: "description of " + name,
+		ProjectIDs:  append([]string{}, projectIDs...),
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func expectCollection(t *testing.T, want, got *models.Collection) {
+	t.Helper()
+	same := got.ID == want.ID && got.Name == want.Name &&
+		got.Description == want.Description &&
+		reflect.DeepEqual(got.ProjectIDs, want.ProjectIDs) &&
+		got.CreatedAt.Equal(want.CreatedAt)
+	if !same {
+		t.Fatalf("got collection %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package repotest
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+)
+
+// RunIdempotencyStore checks that an IdempotencyStore behaves like the postgres repo
+func RunIdempotencyStore(t *testing.T, newStore func(t *testing.T) service.IdempotencyStore) {
+	t.Run("ReserveTaken", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		previous, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+		if previous != nil {
+			t.Fatalf("ReserveIdempotencyKey: got %+v, want the key to be reserved", previous)
+		}
+
+		retry := *record
+		retry.RequestHash = uuid.New().String()
+		previous, err = store.ReserveIdempotencyKey(ctx, &retry, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey of a taken key", err)
+		if previous == nil || previous.RequestHash != record.RequestHash || previous.Completed() {
+			t.Fatalf("ReserveIdempotencyKey of a taken key: got %+v, want the pending record", previous)
+		}
+	})
+
+	t.Run("Scopes", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		other := *record
+		other.Scope = "versions.Create"
+		for _, r := range []*models.IdempotencyRecord{record, &other} {
+			previous, err := store.ReserveIdempotencyKey(ctx, r, r.CreatedAt.Add(-time.Hour))
+			expectOK(t, "ReserveIdempotencyKey", err)
+			if previous != nil {
+				t.Fatalf("ReserveIdempotencyKey in scope %s: got %+v, want the key to be reserved", r.Scope, previous)
+			}
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		_, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+
+		record.Response, record.Revision = []byte("response"), 1
+		err = store.CompleteIdempotencyKey(ctx, record)
+		expectOK(t, "CompleteIdempotencyKey", err)
+		previous, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey of a completed key", err)
+		if previous == nil || !bytes.Equal(previous.Response, record.Response) || previous.Revision != record.Revision {
+			t.Fatalf("ReserveIdempotencyKey of a completed key: got %+v, want %+v", previous, record)
+		}
+
+		// A completed key is kept, its response is replayed
+		err = store.ReleaseIdempotencyKey(ctx, record)
+		expectOK(t, "ReleaseIdempotencyKey", err)
+		previous, err = store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+		if previous == nil || !previous.Completed() {
+			t.Fatalf("ReserveIdempotencyKey of a released completed key: got %+v, want the completed record", previous)
+		}
+
+		// Even after its lease
+		later := *record
+		later.CreatedAt, later.LockedUntil = record.LockedUntil, record.LockedUntil.Add(time.Minute)
+		previous, err = store.ReserveIdempotencyKey(ctx, &later, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey after the lease", err)
+		if previous == nil || !previous.Completed() {
+			t.Fatalf("ReserveIdempotencyKey of a completed key after the lease: got %+v, want the completed record", previous)
+		}
+	})
+
+	t.Run("TakeOver", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		_, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+
+		// The call died, a retry made once its lease ended takes the key over
+		retry := *record
+		retry.RequestHash = uuid.New().String()
+		retry.CreatedAt, retry.LockedUntil = record.LockedUntil, record.LockedUntil.Add(time.Minute)
+		previous, err := store.ReserveIdempotencyKey(ctx, &retry, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey after the lease", err)
+		if previous != nil {
+			t.Fatalf("ReserveIdempotencyKey after the lease: got %+v, want the key to be taken over", previous)
+		}
+
+		// The first call doesn't hold the key anymore
+		record.Response = []byte("response")
+		err = store.CompleteIdempotencyKey(ctx, record)
+		expectKind(t, "CompleteIdempotencyKey of a key taken over", models.KindNotFound, err)
+		err = store.ReleaseIdempotencyKey(ctx, record)
+		expectOK(t, "ReleaseIdempotencyKey of a key taken over", err)
+
+		other := retry
+		other.RequestHash = uuid.New().String()
+		previous, err = store.ReserveIdempotencyKey(ctx, &other, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey within the new lease", err)
+		if previous == nil || previous.RequestHash != retry.RequestHash || !previous.LockedUntil.Equal(retry.LockedUntil) {
+			t.Fatalf("ReserveIdempotencyKey within the new lease: got %+v, want the record of the retry", previous)
+		}
+
+		retry.Response, retry.Revision = []byte("response"), 1
+		err = store.CompleteIdempotencyKey(ctx, &retry)
+		expectOK(t, "CompleteIdempotencyKey of the retry", err)
+	})
+
+	t.Run("CompleteMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		record.Response = []byte("response")
+		err := store.CompleteIdempotencyKey(ctx, record)
+		expectKind(t, "CompleteIdempotencyKey", models.KindNotFound, err)
+	})
+
+	t.Run("Release", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		_, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+
+		err = store.ReleaseIdempotencyKey(ctx, record)
+		expectOK(t, "ReleaseIdempotencyKey", err)
+		previous, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey of a released key", err)
+		if previous != nil {
+			t.Fatalf("ReserveIdempotencyKey of a released key: got %+v, want the key to be reserved", previous)
+		}
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		record := newIdempotencyRecord("projects.Create")
+		_, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey", err)
+
+		later := *record
+		later.CreatedAt = record.CreatedAt.Add(2 * time.Hour)
+		previous, err := store.ReserveIdempotencyKey(ctx, &later, later.CreatedAt.Add(-time.Hour))
+		expectOK(t, "ReserveIdempotencyKey of an expired key", err)
+		if previous != nil {
+			t.Fatalf("ReserveIdempotencyKey of an expired key: got %+v, want the key to be reserved", previous)
+		}
+	})
+
+	t.Run("ConcurrentReserve", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		const callers = 12
+		record := newIdempotencyRecord("projects.Create")
+
+		reserved := make(chan bool, callers)
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				previous, err := store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-time.Hour))
+				if err != nil {
+					t.Errorf("concurrent ReserveIdempotencyKey: got %v", err)
+				}
+				reserved <- err == nil && previous == nil
+			}()
+		}
+		wg.Wait()
+		close(reserved)
+
+		count := 0
+		for ok := range reserved {
+			if ok {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Fatalf("concurrent ReserveIdempotencyKey: %d callers reserved the key, want 1", count)
+		}
+	})
+}
+
+func newIdempotencyRecord(scope string) *models.IdempotencyRecord {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	return &models.IdempotencyRecord{
+		Scope:       scope,
+		Key:         uuid.New().String(),
+		RequestHash: uuid.New().String(),
+		CreatedAt:   now,
+		LockedUntil: now.Add(time.Minute),
+	}
+}
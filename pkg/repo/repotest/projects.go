@@ -0,0 +1,650 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// RunProjectStore checks that a ProjectStore behaves like the postgres repo
+func RunProjectStore(t *testing.T, newStore func(t *testing.T) service.ProjectStore) {
+	t.Run("CreateGet", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("create")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		got, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		expectProject(t, project, got.ProjectInfo)
+		expectRevision(t, "GetProject", 1, got.Revision)
+	})
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("original")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		duplicate := newProject("duplicate")
+		duplicate.Id = project.GetId()
+		err = store.CreateProject(ctx, duplicate, time.Now().UTC())
+		expectKind(t, "CreateProject with an existing id", models.KindConflict, err)
+
+		got, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		expectProject(t, project, got.ProjectInfo)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		_, err := store.GetProject(ctx, &projects.ProjectId{Id: uuid.New().String()})
+		expectKind(t, "GetProject", models.KindNotFound, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("before")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		updated := newProject("after")
+		updated.Id = project.GetId()
+		updated.Metadata.Daw = projects.DAW(1)
+		revision, err := store.UpdateProject(ctx, updated, 1, models.ProjectFields, time.Now().UTC())
+		expectOK(t, "UpdateProject", err)
+		expectRevision(t, "UpdateProject", 2, revision)
+
+		got, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		expectProject(t, updated, got.ProjectInfo)
+		expectRevision(t, "GetProject", 2, got.Revision)
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		// Stores keep the times they're given, not their own
+		createdAt := time.Date(2024, 3, 1, 9, 30, 0, 123456000, time.UTC)
+		project := newProject("before")
+		expectOK(t, "CreateProject", store.CreateProject(ctx, project, createdAt))
+		created, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		if !created.CreatedAt.Equal(createdAt) || !created.UpdatedAt.Equal(createdAt) || created.LastVersionAt != nil {
+			t.Fatalf("GetProject: got created_at %v, updated_at %v and last_version_at %v, want %v, %v and none", created.CreatedAt, created.UpdatedAt, created.LastVersionAt, createdAt, createdAt)
+		}
+
+		updatedAt := createdAt.Add(time.Hour)
+		project.Metadata.Name = "after"
+		_, err = store.UpdateProject(ctx, project, 1, []string{"name"}, updatedAt)
+		expectOK(t, "UpdateProject", err)
+		updated, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		if !updated.CreatedAt.Equal(createdAt) || !updated.UpdatedAt.Equal(updatedAt) {
+			t.Fatalf("GetProject: got created_at %v and updated_at %v after an update, want %v and %v", updated.CreatedAt, updated.UpdatedAt, createdAt, updatedAt)
+		}
+	})
+
+	t.Run("UpdateFields", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("before")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		// Only the name and the bpm are written, the rest is kept
+		partial := &projects.ProjectInfo{
+			Id:       project.GetId(),
+			Metadata: &projects.ProjectMeta{Name: "after", Bpm: 90, Genre: "ignored"},
+		}
+		revision, err := store.UpdateProject(ctx, partial, 1, []string{"name", "bpm"}, time.Now().UTC())
+		expectOK(t, "UpdateProject", err)
+		expectRevision(t, "UpdateProject", 2, revision)
+
+		want := proto.Clone(project).(*projects.ProjectInfo)
+		want.Metadata.Name = "after"
+		want.Metadata.Bpm = 90
+		got, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		expectProject(t, want, got.ProjectInfo)
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		_, err := store.UpdateProject(ctx, newProject("missing"), 1, models.ProjectFields, time.Now().UTC())
+		expectKind(t, "UpdateProject", models.KindNotFound, err)
+	})
+
+	t.Run("UpdateStaleRevision", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("read twice")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		first := newProject("first writer")
+		first.Id = project.GetId()
+		_, err = store.UpdateProject(ctx, first, 1, models.ProjectFields, time.Now().UTC())
+		expectOK(t, "UpdateProject", err)
+
+		second := newProject("second writer")
+		second.Id = project.GetId()
+		_, err = store.UpdateProject(ctx, second, 1, models.ProjectFields, time.Now().UTC())
+		expectKind(t, "UpdateProject at a stale revision", models.KindStale, err)
+		expectRevision(t, "UpdateProject at a stale revision", 2, staleRevision(err))
+
+		got, err := store.GetProject(ctx, project.GetId())
+		expectOK(t, "GetProject", err)
+		expectProject(t, first, got.ProjectInfo)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		project := newProject("deleted")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		err = store.DeleteProject(ctx, project.GetId())
+		expectOK(t, "DeleteProject", err)
+		_, err = store.GetProject(ctx, project.GetId())
+		expectKind(t, "GetProject after DeleteProject", models.KindNotFound, err)
+		err = store.DeleteProject(ctx, project.GetId())
+		expectKind(t, "DeleteProject twice", models.KindNotFound, err)
+	})
+
+	t.Run("MissingProjects", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		ids := createProjects(t, store, "Tape Loops", "Nebula Drift", "deleted")
+		expectOK(t, "DeleteProject", store.DeleteProject(ctx, &projects.ProjectId{Id: ids[2]}))
+		unknown := uuid.New().String()
+
+		missing, err := store.MissingProjects(ctx, []string{unknown, ids[0], ids[2], ids[1]})
+		expectOK(t, "MissingProjects", err)
+		if want := []string{unknown, ids[2]}; !reflect.DeepEqual(missing, want) {
+			t.Errorf("MissingProjects: got %v, want %v", missing, want)
+		}
+		missing, err = store.MissingProjects(ctx, ids[:2])
+		expectOK(t, "MissingProjects of projects", err)
+		if len(missing) != 0 {
+			t.Errorf("MissingProjects of projects: got %v, want none", missing)
+		}
+	})
+
+	t.Run("ListPaging", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		created := map[string]*projects.ProjectInfo{}
+		for i := 0; i < 7; i++ {
+			project := newProject(fmt.Sprintf("paged %d", i))
+			err := store.CreateProject(ctx, project, time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+			created[project.GetId().GetId()] = project
+		}
+
+		// Page is the number of projects skipped, like an OFFSET
+		var listed []string
+		for page := int32(0); page < 9; page += 3 {
+			got := listProjects(t, store, 3, page)
+			if want := minInt(3, 7-int(page)); len(got) != want {
+				t.Fatalf("ListProjects(count 3, page %d): got %d projects, want %d", page, len(got), want)
+			}
+			for _, project := range got {
+				expectProject(t, created[project.GetId().GetId()], project.ProjectInfo)
+				expectRevision(t, "ListProjects", 1, project.Revision)
+				listed = append(listed, project.GetId().GetId())
+			}
+		}
+		seen := map[string]bool{}
+		for _, id := range listed {
+			if seen[id] {
+				t.Fatalf("ListProjects: project %s is on more than one page", id)
+			}
+			seen[id] = true
+		}
+		if len(seen) != len(created) {
+			t.Fatalf("ListProjects: got %d projects over all pages, want %d", len(seen), len(created))
+		}
+	})
+
+	t.Run("ListAfter", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		for i := 0; i < 7; i++ {
+			err := store.CreateProject(ctx, newProject(fmt.Sprintf("after %d", i)), time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+		}
+
+		// Each page starts after the last project of the previous one, projects
+		// created meanwhile before it don't shift the next pages
+		var listed []string
+		list := &models.ProjectQuery{ListQuery: models.ListQuery{Limit: 3}}
+		for {
+			got := queryProjects(t, store, list)
+			for _, project := range got {
+				listed = append(listed, project.GetId().GetId())
+			}
+			if len(got) < list.Limit {
+				break
+			}
+			list.After = listed[len(listed)-1]
+			before := newProject("before")
+			before.Id.Id = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(listed))
+			err := store.CreateProject(ctx, before, time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+		}
+		if len(listed) != 7 {
+			t.Fatalf("ListProjects: got %d projects over all pages, want 7", len(listed))
+		}
+		for i := 1; i < len(listed); i++ {
+			if listed[i-1] >= listed[i] {
+				t.Fatalf("ListProjects: projects are not sorted by id, %s is listed before %s", listed[i-1], listed[i])
+			}
+		}
+	})
+
+	t.Run("ListFilter", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		private, template, fl := false, false, projects.DAW_FLSTUDIO
+		want := map[string]bool{}
+		for i, meta := range []*projects.ProjectMeta{
+			{Name: "match", Genre: "House", Key: "Am", Bpm: 124, Daw: fl},
+			{Name: "match", Genre: "house", Key: "Am", Bpm: 120, Daw: fl},
+			{Name: "other genre", Genre: "techno", Key: "Am", Bpm: 124, Daw: fl},
+			{Name: "other key", Genre: "house", Key: "C", Bpm: 124, Daw: fl},
+			{Name: "too slow", Genre: "house", Key: "Am", Bpm: 110, Daw: fl},
+			{Name: "too fast", Genre: "house", Key: "Am", Bpm: 130, Daw: fl},
+			{Name: "public", Genre: "house", Key: "Am", Bpm: 124, Daw: fl, Public: true},
+			{Name: "other daw", Genre: "house", Key: "Am", Bpm: 124},
+		} {
+			project := newProject(meta.Name)
+			project.Metadata = meta
+			err := store.CreateProject(ctx, project, time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+			if i < 2 {
+				want[project.GetId().GetId()] = true
+			}
+		}
+
+		got := queryProjects(t, store, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter: models.ProjectFilter{
+				DAW: &fl, Genre: "HOUSE", Keys: []string{"Am"},
+				MinBpm: 120, MaxBpm: 125, Public: &private, Template: &template,
+			},
+		})
+		if len(got) != len(want) {
+			t.Fatalf("ListProjects with a filter: got %d projects, want %d", len(got), len(want))
+		}
+		for _, project := range got {
+			if !want[project.GetId().GetId()] {
+				t.Fatalf("ListProjects with a filter: got %q, it doesn't pass the filter", project.GetMetadata().GetName())
+			}
+		}
+	})
+
+	t.Run("ListTemplate", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		err := store.CreateProject(ctx, newProject("sketch"), time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		// Projects are created as no template, the proto can't set the flag
+		template := true
+		got := queryProjects(t, store, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{Template: &template},
+		})
+		if len(got) != 0 {
+			t.Fatalf("ListProjects of templates: got %d projects, want none", len(got))
+		}
+	})
+
+	t.Run("ListHarmonic", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		var reference string
+		want := map[string]bool{}
+		for i, meta := range []*projects.ProjectMeta{
+			{Name: "reference", Key: "Am", Bpm: 124},
+			{Name: "same key", Key: "Am", Bpm: 126},
+			{Name: "relative", Key: "C", Bpm: 62},
+			{Name: "neighbour", Key: "Em", Bpm: 248},
+			{Name: "other key", Key: "F#", Bpm: 124},
+			{Name: "other tempo", Key: "Am", Bpm: 140},
+		} {
+			project := newProject(meta.Name)
+			project.Metadata = meta
+			err := store.CreateProject(ctx, project, time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+			switch {
+			case i == 0:
+				reference = project.GetId().GetId()
+			case i < 4:
+				want[project.GetId().GetId()] = true
+			}
+		}
+
+		got := queryProjects(t, store, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter: models.ProjectFilter{
+				Keys:      []string{"Am", "C", "Em", "Dm"},
+				BpmRanges: []models.BpmRange{{Min: 120, Max: 128}, {Min: 60, Max: 64}, {Min: 240, Max: 256}},
+				ExcludeID: reference,
+			},
+		})
+		if len(got) != len(want) {
+			t.Fatalf("ListProjects in harmony: got %d projects, want %d", len(got), len(want))
+		}
+		for _, project := range got {
+			if !want[project.GetId().GetId()] {
+				t.Fatalf("ListProjects in harmony: got %q, it doesn't pass the filter", project.GetMetadata().GetName())
+			}
+		}
+	})
+
+	t.Run("ListTimes", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		var stored []*models.Project
+		for _, name := range []string{"old", "middle", "new"} {
+			project := newProject(name)
+			expectOK(t, "CreateProject", store.CreateProject(ctx, project, time.Now().UTC()))
+			got, err := store.GetProject(ctx, project.GetId())
+			expectOK(t, "GetProject", err)
+			stored = append(stored, got)
+			time.Sleep(time.Millisecond)
+		}
+		stored[0].Metadata.Name = "old, updated"
+		_, err := store.UpdateProject(ctx, stored[0].ProjectInfo, 1, []string{"name"}, time.Now().UTC())
+		expectOK(t, "UpdateProject", err)
+
+		got := queryProjects(t, store, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter: models.ProjectFilter{
+				Created: models.TimeRange{From: stored[1].CreatedAt},
+				Updated: models.TimeRange{To: stored[1].UpdatedAt},
+			},
+		})
+		if len(got) != 1 || got[0].GetId().GetId() != stored[1].GetId().GetId() {
+			t.Fatalf("ListProjects by times: got %d projects, want only %q", len(got), stored[1].GetMetadata().GetName())
+		}
+		got = queryProjects(t, store, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{LastVersion: models.TimeRange{To: time.Now().UTC()}},
+		})
+		if len(got) != 0 {
+			t.Fatalf("ListProjects by last version: got %d projects without versions, want none", len(got))
+		}
+	})
+
+	t.Run("ListSorted", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		for i, name := range []string{"b", "A", "c", "B", "a", "C", "d"} {
+			project := newProject(name)
+			project.Metadata.Bpm = int32(120 + i%3)
+			err := store.CreateProject(ctx, project, time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+		}
+
+		for _, order := range []struct {
+			field      string
+			descending bool
+		}{
+			{models.SortByName, false}, {models.SortByName, true},
+			{models.SortByBpm, false}, {models.SortByBpm, true},
+			{models.SortByCreatedAt, false}, {models.SortByUpdatedAt, true},
+		} {
+			// Pages of 2 cut through projects with the same value
+			var listed []*models.Project
+			list := &models.ProjectQuery{ListQuery: models.ListQuery{Limit: 2}, OrderBy: order.field, Descending: order.descending}
+			for {
+				got := queryProjects(t, store, list)
+				listed = append(listed, got...)
+				if len(got) < list.Limit {
+					break
+				}
+				last := got[len(got)-1]
+				list.After, list.AfterValue = last.GetId().GetId(), last.SortValue(order.field)
+			}
+			if len(listed) != 7 {
+				t.Fatalf("ListProjects by %s: got %d projects over all pages, want 7", order.field, len(listed))
+			}
+			for i := 1; i < len(listed); i++ {
+				a, b := listed[i-1], listed[i]
+				if cmp := compareBy(order.field, a, b); order.descending && cmp < 0 || !order.descending && cmp > 0 {
+					t.Fatalf("ListProjects by %s (descending %t): %q is listed before %q", order.field, order.descending, a.GetMetadata().GetName(), b.GetMetadata().GetName())
+				}
+			}
+		}
+	})
+
+	t.Run("ListOrder", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		for i := 0; i < 5; i++ {
+			err := store.CreateProject(ctx, newProject(fmt.Sprintf("ordered %d", i)), time.Now().UTC())
+			expectOK(t, "CreateProject", err)
+		}
+		first, second := listProjects(t, store, 10, 0), listProjects(t, store, 10, 0)
+		for i := range first {
+			if first[i].GetId().GetId() != second[i].GetId().GetId() {
+				t.Fatalf("ListProjects: the order changed between two calls")
+			}
+		}
+	})
+
+	t.Run("ListWhileSending", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		createProjects(t, store, "Tape Loops", "Nebula Drift")
+		// A client still reading the list mustn't block the other calls
+		sent := 0
+		stream := projectsStreamFunc(func(project *models.Project) error {
+			sent++
+			_, err := store.GetProject(ctx, project.GetId())
+			return err
+		})
+		errs := make(chan error, 1)
+		go func() {
+			errs <- store.ListProjects(ctx, stream, &models.ProjectQuery{ListQuery: models.ListQuery{Limit: 10}})
+		}()
+		select {
+		case err := <-errs:
+			expectOK(t, "GetProject while ListProjects is sending", err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("GetProject while ListProjects is sending: blocked")
+		}
+		if sent != 2 {
+			t.Fatalf("ListProjects: sent %d projects, want 2", sent)
+		}
+	})
+
+	t.Run("ListEmpty", func(t *testing.T) {
+		store := newStore(t)
+		if got := listProjects(t, store, 10, 0); len(got) != 0 {
+			t.Fatalf("ListProjects: got %d projects from an empty store", len(got))
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		const writers = 8
+		project := newProject("contended")
+		errs := make(chan error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Every writer creates a project of its own, and tries the contended one
+				if err := store.CreateProject(ctx, newProject(fmt.Sprintf("writer %d", i)), time.Now().UTC()); err != nil {
+					t.Errorf("CreateProject: got %v, want no error", err)
+				}
+				errs <- store.CreateProject(ctx, proto.Clone(project).(*projects.ProjectInfo), time.Now().UTC())
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case models.KindOf(err) == models.KindConflict:
+			default:
+				t.Fatalf("concurrent CreateProject: got %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("concurrent CreateProject of one id: %d writers succeeded, want 1", created)
+		}
+		if got := listProjects(t, store, 100, 0); len(got) != writers+1 {
+			t.Fatalf("ListProjects: got %d projects, want %d", len(got), writers+1)
+		}
+	})
+
+	t.Run("ConcurrentUpdate", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		const writers = 8
+		project := newProject("contended")
+		err := store.CreateProject(ctx, project, time.Now().UTC())
+		expectOK(t, "CreateProject", err)
+
+		errs := make(chan error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				// Every writer read the first revision
+				update := newProject(fmt.Sprintf("writer %d", i))
+				update.Id = project.GetId()
+				_, err := store.UpdateProject(ctx, update, 1, models.ProjectFields, time.Now().UTC())
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		updated := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				updated++
+			case models.KindOf(err) == models.KindStale:
+			default:
+				t.Fatalf("concurrent UpdateProject: got %v", err)
+			}
+		}
+		if updated != 1 {
+			t.Fatalf("concurrent UpdateProject at one revision: %d writers succeeded, want 1", updated)
+		}
+	})
+}
+
+// RunLegacyProjects checks that projects kept by older migrations pass the
+// rules of the service, they're read and written back whole through it
+func RunLegacyProjects(t *testing.T, store service.ProjectStore, ids ...string) {
+	ctx := context.Background()
+	projectService := service.NewProjectService(store, nil, nil, time.Now, service.NewUUID)
+	for _, id := range ids {
+		got, err := projectService.Get(ctx, &projects.ProjectId{Id: id})
+		expectOK(t, "Get of "+id, err)
+		_, err = projectService.Update(ctx, got.ProjectInfo, got.Revision, nil)
+		expectOK(t, fmt.Sprintf("Update of %s with key %q", id, got.GetMetadata().GetKey()), err)
+	}
+}
+
+func newProject(name string) *projects.ProjectInfo {
+	return &projects.ProjectInfo{
+		Id: &projects.ProjectId{Id: uuid.New().String()},
+		Metadata: &projects.ProjectMeta{
+			Name:        name,
+			Description: "description of " + name,
+			Public:      true,
+			Bpm:         124,
+			Key:         "Am",
+			Genre:       "house",
+		},
+	}
+}
+
+func expectProject(t *testing.T, want, got *projects.ProjectInfo) {
+	t.Helper()
+	if want == nil {
+		t.Fatalf("got unexpected project %v", got)
+	}
+	if !proto.Equal(want, got) {
+		t.Fatalf("got project %v, want %v", got, want)
+	}
+}
+
+func listProjects(t *testing.T, store service.ProjectStore, count, page int32) []*models.Project {
+	t.Helper()
+	return queryProjects(t, store, &models.ProjectQuery{ListQuery: models.ListQuery{Limit: int(count), Offset: int(page)}})
+}
+
+func queryProjects(t *testing.T, store service.ProjectStore, list *models.ProjectQuery) []*models.Project {
+	t.Helper()
+	stream := &projectsStream{}
+	err := store.ListProjects(context.Background(), stream, list)
+	expectOK(t, "ListProjects", err)
+	return stream.sent
+}
+
+// projectsStream keeps what's sent, copied, since repos may reuse messages
+type projectsStream struct {
+	sent []*models.Project
+}
+
+func (s *projectsStream) Send(project *models.Project) error {
+	sent := *project
+	sent.ProjectInfo = proto.Clone(project.ProjectInfo).(*projects.ProjectInfo)
+	s.sent = append(s.sent, &sent)
+	return nil
+}
+
+// projectsStreamFunc is a stream calling a function with what's sent
+type projectsStreamFunc func(project *models.Project) error
+
+func (f projectsStreamFunc) Send(project *models.Project) error {
+	return f(project)
+}
+
+// compareBy compares projects like stores sort them, names are compared
+// without case and equal values by id
+func compareBy(field string, a, b *models.Project) int {
+	switch field {
+	case models.SortByName:
+		if cmp := strings.Compare(strings.ToLower(a.GetMetadata().GetName()), strings.ToLower(b.GetMetadata().GetName())); cmp != 0 {
+			return cmp
+		}
+	case models.SortByBpm:
+		if a.GetMetadata().GetBpm() != b.GetMetadata().GetBpm() {
+			return int(a.GetMetadata().GetBpm() - b.GetMetadata().GetBpm())
+		}
+	case models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByLastVersionAt:
+		at, aok := a.SortValue(field).(time.Time)
+		bt, bok := b.SortValue(field).(time.Time)
+		// Projects without versions come last whatever the direction, it's
+		// checked apart
+		if aok != bok {
+			return 0
+		}
+		if !at.Equal(bt) {
+			if at.Before(bt) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a.GetId().GetId(), b.GetId().GetId())
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,53 @@
+// Package repotest is the conformance suite of the stores used by the
+// service layer. Every implementation, postgres, memory or any other, must
+// pass it:
+//
+//	func TestProjectRepo(t *testing.T) {
+//		repotest.RunProjectStore(t, func(t *testing.T) service.ProjectStore {
+//			return memory.NewProjectRepo()
+//		})
+//	}
+//
+// Every test gets a new, empty store from the factory.
+package repotest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// expectOK fails the test if a store returned an error
+func expectOK(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: got %v, want no error", op, err)
+	}
+}
+
+// expectKind fails the test unless a store returned a domain error of the
+// wanted kind
+func expectKind(t *testing.T, op string, want models.ErrorKind, err error) {
+	t.Helper()
+	if got := models.KindOf(err); got != want {
+		t.Fatalf("%s: got %v (%s), want a %s error", op, err, got, want)
+	}
+}
+
+// staleRevision is the current revision held by a models.KindStale error
+func staleRevision(err error) int64 {
+	var domain *models.Error
+	if errors.As(err, &domain) {
+		return domain.Revision
+	}
+	return 0
+}
+
+// expectRevision fails the test unless a store returned the wanted revision
+func expectRevision(t *testing.T, op string, want, got int64) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: got revision %d, want %d", op, got, want)
+	}
+}
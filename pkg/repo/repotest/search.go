@@ -0,0 +1,209 @@
+package repotest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+)
+
+// SearchStores are a search store and the stores of what it searches
+type SearchStores struct {
+	Projects service.ProjectStore
+	Versions service.VersionStore
+	Search   service.SearchStore
+}
+
+// RunSearchStore checks that a SearchStore behaves like the postgres repo.
+// Ranks aren't compared between stores, only the order of the hits.
+func RunSearchStore(t *testing.T, newStores func(t *testing.T) SearchStores) {
+	t.Run("Fields", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		named := newProject("Nebula Drift")
+		described := newProject("Tape Loops")
+		described.Metadata.Description = "pads sampled from an old nebula documentary"
+		other := newProject("Sunday Morning")
+		for _, project := range []*projects.ProjectInfo{named, described, other} {
+			expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+		}
+		version := newVersion(other.GetId().GetId(), 1)
+		version.Metadata.Message = "bounced the nebula stems"
+		expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, version))
+
+		hits := search(t, stores.Search, &models.SearchQuery{Text: "nebula"})
+		if len(hits) != 3 {
+			t.Fatalf("Search: got %d hits, want 3", len(hits))
+		}
+		if hits[0].Kind != models.HitProject || hits[0].ID != named.GetId().GetId() {
+			t.Fatalf("Search: got %s %s first, want the project named after the text", hits[0].Kind, hits[0].ID)
+		}
+		expectSnippet(t, hits[0], "name")
+		for _, hit := range hits[1:] {
+			switch hit.ID {
+			case described.GetId().GetId():
+				expectSnippet(t, hit, "description")
+			case version.GetId().GetId():
+				if hit.Kind != models.HitVersion || hit.ProjectID != other.GetId().GetId() {
+					t.Fatalf("Search: got version hit %+v, want project %s", hit, other.GetId().GetId())
+				}
+				expectSnippet(t, hit, "message")
+			default:
+				t.Fatalf("Search: got unexpected hit %+v", hit)
+			}
+		}
+	})
+
+	t.Run("Escaped", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, newProject("<img src=x onerror=alert(1)> Nebula & Drift"), time.Now().UTC()))
+
+		hits := search(t, stores.Search, &models.SearchQuery{Text: "nebula"})
+		if len(hits) != 1 {
+			t.Fatalf("Search: got %d hits, want 1", len(hits))
+		}
+		expectSnippet(t, hits[0], "name")
+		// Only the highlights are markup
+		snippet := strings.NewReplacer(models.HighlightStart, "", models.HighlightStop, "").Replace(hits[0].Snippets["name"])
+		if snippet != "&lt;img src=x onerror=alert(1)&gt; Nebula &amp; Drift" {
+			t.Fatalf("Search: got name snippet %q, want the name escaped", hits[0].Snippets["name"])
+		}
+	})
+
+	t.Run("AllWords", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		both := newProject("Nebula Drift")
+		one := newProject("Nebula Rise")
+		for _, project := range []*projects.ProjectInfo{both, one} {
+			expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+		}
+
+		hits := search(t, stores.Search, &models.SearchQuery{Text: "nebula drift"})
+		if len(hits) != 1 || hits[0].ID != both.GetId().GetId() {
+			t.Fatalf("Search: got %+v, want only project %s", hits, both.GetId().GetId())
+		}
+		if hits := search(t, stores.Search, &models.SearchQuery{Text: "quasar"}); len(hits) != 0 {
+			t.Fatalf("Search of a missing word: got %d hits, want none", len(hits))
+		}
+	})
+
+	t.Run("UpdateDelete", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		project := newProject("Nebula Drift")
+		project.Metadata.Description = "ambient sketch"
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+
+		project.Metadata.Name = "Quasar Drift"
+		_, err := stores.Projects.UpdateProject(ctx, project, 1, []string{"name"}, time.Now().UTC())
+		expectOK(t, "UpdateProject", err)
+		if hits := search(t, stores.Search, &models.SearchQuery{Text: "nebula"}); len(hits) != 0 {
+			t.Fatalf("Search of the old name: got %d hits, want none", len(hits))
+		}
+		if hits := search(t, stores.Search, &models.SearchQuery{Text: "quasar"}); len(hits) != 1 {
+			t.Fatalf("Search of the new name: got %d hits, want 1", len(hits))
+		}
+
+		expectOK(t, "DeleteProject", stores.Projects.DeleteProject(ctx, project.GetId()))
+		if hits := search(t, stores.Search, &models.SearchQuery{Text: "quasar"}); len(hits) != 0 {
+			t.Fatalf("Search of a deleted project: got %d hits, want none", len(hits))
+		}
+	})
+
+	t.Run("Restricted", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		shared, private := newProject("Nebula Drift"), newProject("Nebula Rise")
+		for _, project := range []*projects.ProjectInfo{shared, private} {
+			expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+		}
+		first, second := newVersion(shared.GetId().GetId(), 1), newVersion(shared.GetId().GetId(), 2)
+		first.Metadata.Message, second.Metadata.Message = "nebula rough mix", "nebula master"
+		for _, version := range []*versions.VersionInfo{first, second} {
+			expectOK(t, "CreateVersion", stores.Versions.CreateVersion(ctx, version))
+		}
+
+		hits := search(t, stores.Search, &models.SearchQuery{Text: "nebula", ProjectID: shared.GetId().GetId()})
+		if len(hits) != 3 {
+			t.Fatalf("Search of a project: got %d hits, want the project and its 2 versions", len(hits))
+		}
+		hits = search(t, stores.Search, &models.SearchQuery{
+			Text:      "nebula",
+			ProjectID: shared.GetId().GetId(),
+			VersionID: second.GetId().GetId(),
+		})
+		for _, hit := range hits {
+			if hit.ProjectID != shared.GetId().GetId() || hit.Kind == models.HitVersion && hit.ID != second.GetId().GetId() {
+				t.Fatalf("Search of a version: got unexpected hit %+v", hit)
+			}
+		}
+		if len(hits) != 2 {
+			t.Fatalf("Search of a version: got %d hits, want the project and the version", len(hits))
+		}
+	})
+
+	t.Run("Pages", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		for i := 0; i < 5; i++ {
+			expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, newProject("Nebula"), time.Now().UTC()))
+		}
+
+		all := search(t, stores.Search, &models.SearchQuery{Text: "nebula", ListQuery: models.ListQuery{Limit: 10}})
+		if len(all) != 5 {
+			t.Fatalf("Search: got %d hits, want 5", len(all))
+		}
+		for i := 1; i < len(all); i++ {
+			if all[i-1].ID >= all[i].ID {
+				t.Fatalf("Search: hits of the same rank aren't sorted by id")
+			}
+		}
+		var paged []*models.SearchHit
+		for offset := 0; offset < 6; offset += 2 {
+			paged = append(paged, search(t, stores.Search, &models.SearchQuery{
+				Text:      "nebula",
+				ListQuery: models.ListQuery{Limit: 2, Offset: offset},
+			})...)
+		}
+		if len(paged) != len(all) {
+			t.Fatalf("Search by pages: got %d hits, want %d", len(paged), len(all))
+		}
+		for i := range all {
+			if paged[i].ID != all[i].ID {
+				t.Fatalf("Search by pages: got %s at %d, want %s", paged[i].ID, i, all[i].ID)
+			}
+		}
+	})
+}
+
+// search runs a query, a query without a limit gets one large enough for
+// every test
+func search(t *testing.T, store service.SearchStore, query *models.SearchQuery) []*models.SearchHit {
+	t.Helper()
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	stream := &searchStream{}
+	err := store.Search(context.Background(), stream, query)
+	expectOK(t, "Search", err)
+	return stream.sent
+}
+
+type searchStream struct {
+	sent []*models.SearchHit
+}
+
+func (s *searchStream) Send(hit *models.SearchHit) error {
+	s.sent = append(s.sent, hit)
+	return nil
+}
+
+// expectSnippet fails the test unless the field of the hit is highlighted
+func expectSnippet(t *testing.T, hit *models.SearchHit, field string) {
+	t.Helper()
+	snippet := hit.Snippets[field]
+	if !strings.Contains(strings.ToLower(snippet), models.HighlightStart+"nebula"+models.HighlightStop) {
+		t.Fatalf("Search: got %s snippet %q, want nebula highlighted", field, snippet)
+	}
+}
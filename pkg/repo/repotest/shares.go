@@ -0,0 +1,179 @@
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+)
+
+// RunShareLinkStore checks that a ShareLinkStore behaves like the postgres repo
+func RunShareLinkStore(t *testing.T, newStore func(t *testing.T) service.ShareLinkStore) {
+	t.Run("CreateGet", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		link := newShareLink(0, nil)
+		link.VersionID = uuid.New().String()
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		got, err := store.GetShareLinkByToken(ctx, link.TokenHash)
+		expectOK(t, "GetShareLinkByToken", err)
+		expectShareLink(t, link, got)
+
+		got, err = store.GetShareLink(ctx, link.ID)
+		expectOK(t, "GetShareLink", err)
+		expectShareLink(t, link, got)
+	})
+
+	t.Run("CreateExistingToken", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		link := newShareLink(0, nil)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		duplicate := newShareLink(0, nil)
+		duplicate.TokenHash = link.TokenHash
+		err = store.CreateShareLink(ctx, duplicate)
+		expectKind(t, "CreateShareLink with an existing token", models.KindConflict, err)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		_, err := store.GetShareLinkByToken(ctx, uuid.New().String())
+		expectKind(t, "GetShareLinkByToken", models.KindNotFound, err)
+		_, err = store.GetShareLink(ctx, uuid.New().String())
+		expectKind(t, "GetShareLink", models.KindNotFound, err)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		link := newShareLink(0, nil)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		err = store.RevokeShareLink(ctx, link.ID)
+		expectOK(t, "RevokeShareLink", err)
+		got, err := store.GetShareLinkByToken(ctx, link.TokenHash)
+		expectOK(t, "GetShareLinkByToken", err)
+		if !got.Revoked {
+			t.Fatalf("GetShareLinkByToken: the link isn't revoked")
+		}
+		err = store.RegisterShareLinkDownload(ctx, link.ID, time.Now().UTC())
+		expectKind(t, "RegisterShareLinkDownload of a revoked link", models.KindPermission, err)
+	})
+
+	t.Run("RevokeMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		err := store.RevokeShareLink(ctx, uuid.New().String())
+		expectKind(t, "RevokeShareLink", models.KindNotFound, err)
+	})
+
+	t.Run("DownloadLimit", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		link := newShareLink(2, nil)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		for i := 0; i < 2; i++ {
+			err = store.RegisterShareLinkDownload(ctx, link.ID, time.Now().UTC())
+			expectOK(t, "RegisterShareLinkDownload", err)
+		}
+		err = store.RegisterShareLinkDownload(ctx, link.ID, time.Now().UTC())
+		expectKind(t, "RegisterShareLinkDownload over the limit", models.KindPermission, err)
+
+		got, err := store.GetShareLinkByToken(ctx, link.TokenHash)
+		expectOK(t, "GetShareLinkByToken", err)
+		if got.Downloads != 2 {
+			t.Fatalf("GetShareLinkByToken: got %d downloads, want 2", got.Downloads)
+		}
+	})
+
+	t.Run("DownloadUnlimited", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		link := newShareLink(0, nil)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		for i := 0; i < 5; i++ {
+			err = store.RegisterShareLinkDownload(ctx, link.ID, time.Now().UTC())
+			expectOK(t, "RegisterShareLinkDownload", err)
+		}
+	})
+
+	t.Run("DownloadExpired", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		// The link expires by the time given to the store, not by its own clock
+		expires := time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond)
+		link := newShareLink(0, &expires)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		err = store.RegisterShareLinkDownload(ctx, link.ID, expires.Add(-time.Minute))
+		expectOK(t, "RegisterShareLinkDownload", err)
+		err = store.RegisterShareLinkDownload(ctx, link.ID, expires.Add(time.Minute))
+		expectKind(t, "RegisterShareLinkDownload of an expired link", models.KindPermission, err)
+	})
+
+	t.Run("ConcurrentDownloads", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		const limit, downloaders = 3, 12
+		link := newShareLink(limit, nil)
+		err := store.CreateShareLink(ctx, link)
+		expectOK(t, "CreateShareLink", err)
+
+		errs := make(chan error, downloaders)
+		var wg sync.WaitGroup
+		for i := 0; i < downloaders; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- store.RegisterShareLinkDownload(ctx, link.ID, time.Now().UTC())
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		downloaded := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				downloaded++
+			case models.KindOf(err) == models.KindPermission:
+			default:
+				t.Fatalf("concurrent RegisterShareLinkDownload: got %v", err)
+			}
+		}
+		if downloaded != limit {
+			t.Fatalf("concurrent RegisterShareLinkDownload: %d downloads succeeded, want %d", downloaded, limit)
+		}
+	})
+}
+
+func newShareLink(maxDownloads int32, expiresAt *time.Time) *models.ShareLink {
+	return &models.ShareLink{
+		ID:           uuid.New().String(),
+		ProjectID:    uuid.New().String(),
+		TokenHash:    uuid.New().String(),
+		ExpiresAt:    expiresAt,
+		MaxDownloads: maxDownloads,
+		CreatedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+}
+
+func expectShareLink(t *testing.T, want, got *models.ShareLink) {
+	t.Helper()
+	same := got.ID == want.ID && got.ProjectID == want.ProjectID &&
+		got.VersionID == want.VersionID && got.TokenHash == want.TokenHash &&
+		got.PasswordHash == want.PasswordHash && got.MaxDownloads == want.MaxDownloads &&
+		got.Downloads == want.Downloads && got.Revoked == want.Revoked &&
+		got.CreatedAt.Equal(want.CreatedAt) &&
+		(got.ExpiresAt == nil) == (want.ExpiresAt == nil) &&
+		(want.ExpiresAt == nil || got.ExpiresAt.Equal(*want.ExpiresAt))
+	if !same {
+		t.Fatalf("got share link %+v, want %+v", got, want)
+	}
+}
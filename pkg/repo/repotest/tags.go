@@ -0,0 +1,107 @@
+package repotest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+)
+
+// TagStores are a tag store and the store of the tagged projects
+type TagStores struct {
+	Projects service.ProjectStore
+	Tags     service.TagStore
+}
+
+// RunTagStore checks that a TagStore behaves like the postgres repo
+func RunTagStore(t *testing.T, newStores func(t *testing.T) TagStores) {
+	t.Run("SetGet", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		project := newProject("Nebula Drift")
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+		id := project.GetId().GetId()
+
+		expectTags(t, stores.Tags, id, []string{})
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, id, []string{"ambient", "demo"}))
+		expectTags(t, stores.Tags, id, []string{"ambient", "demo"})
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, id, []string{"mixed"}))
+		expectTags(t, stores.Tags, id, []string{"mixed"})
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, id, nil))
+		expectTags(t, stores.Tags, id, []string{})
+	})
+
+	t.Run("List", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		for name, tags := range map[string][]string{
+			"Nebula Drift":   {"ambient", "demo"},
+			"Tape Loops":     {"ambient"},
+			"Sunday Morning": nil,
+		} {
+			project := newProject(name)
+			expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+			expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, project.GetId().GetId(), tags))
+		}
+
+		got, err := stores.Tags.ListTags(ctx)
+		expectOK(t, "ListTags", err)
+		want := []*models.TagCount{{Tag: "ambient", Projects: 2}, {Tag: "demo", Projects: 1}}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("ListTags: got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("DeleteProject", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		project := newProject("Nebula Drift")
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, project, time.Now().UTC()))
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, project.GetId().GetId(), []string{"demo"}))
+		expectOK(t, "DeleteProject", stores.Projects.DeleteProject(ctx, project.GetId()))
+
+		expectTags(t, stores.Tags, project.GetId().GetId(), []string{})
+		got, err := stores.Tags.ListTags(ctx)
+		expectOK(t, "ListTags", err)
+		if len(got) != 0 {
+			t.Fatalf("ListTags: got %v of a deleted project", got)
+		}
+	})
+
+	t.Run("ListProjects", func(t *testing.T) {
+		ctx, stores := context.Background(), newStores(t)
+		both := newProject("Nebula Drift")
+		one := newProject("Tape Loops")
+		none := newProject("Sunday Morning")
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, both, time.Now().UTC()))
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, one, time.Now().UTC()))
+		expectOK(t, "CreateProject", stores.Projects.CreateProject(ctx, none, time.Now().UTC()))
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, both.GetId().GetId(), []string{"ambient", "demo"}))
+		expectOK(t, "SetProjectTags", stores.Tags.SetProjectTags(ctx, one.GetId().GetId(), []string{"ambient"}))
+
+		listed := queryProjects(t, stores.Projects, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{Tags: []string{"ambient"}},
+		})
+		if len(listed) != 2 {
+			t.Fatalf("ListProjects: got %d projects tagged ambient, want 2", len(listed))
+		}
+		listed = queryProjects(t, stores.Projects, &models.ProjectQuery{
+			ListQuery: models.ListQuery{Limit: 10},
+			Filter:    models.ProjectFilter{Tags: []string{"ambient", "demo"}},
+		})
+		if len(listed) != 1 {
+			t.Fatalf("ListProjects: got %d projects tagged ambient and demo, want 1", len(listed))
+		}
+		expectProject(t, both, listed[0].ProjectInfo)
+	})
+}
+
+func expectTags(t *testing.T, store service.TagStore, projectID string, want []string) {
+	t.Helper()
+	got, err := store.GetProjectTags(context.Background(), projectID)
+	expectOK(t, "GetProjectTags", err)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetProjectTags: got %v, want %v", got, want)
+	}
+}
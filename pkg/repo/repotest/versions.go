@@ -0,0 +1,376 @@
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// RunVersionStore checks that a VersionStore behaves like the postgres repo
+func RunVersionStore(t *testing.T, newStore func(t *testing.T) service.VersionStore) {
+	t.Run("CreateGet", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		version := newVersion(uuid.New().String(), 1)
+		err := store.CreateVersion(ctx, version)
+		expectOK(t, "CreateVersion", err)
+
+		got, err := store.GetVersions(ctx, version.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, version, got.VersionInfo)
+		expectRevision(t, "GetVersions", 1, got.Revision)
+	})
+
+	t.Run("CreateExisting", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		version := newVersion(uuid.New().String(), 1)
+		err := store.CreateVersion(ctx, version)
+		expectOK(t, "CreateVersion", err)
+
+		duplicate := newVersion(uuid.New().String(), 1)
+		duplicate.Id = version.GetId()
+		err = store.CreateVersion(ctx, duplicate)
+		expectKind(t, "CreateVersion with an existing id", models.KindConflict, err)
+
+		got, err := store.GetVersions(ctx, version.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, version, got.VersionInfo)
+	})
+
+	t.Run("CreateExistingNumber", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		projectID := uuid.New().String()
+		err := store.CreateVersion(ctx, newVersion(projectID, 1))
+		expectOK(t, "CreateVersion", err)
+
+		err = store.CreateVersion(ctx, newVersion(projectID, 1))
+		expectKind(t, "CreateVersion with a taken number", models.KindConflict, err)
+		// Numbers are only unique within a project
+		err = store.CreateVersion(ctx, newVersion(uuid.New().String(), 1))
+		expectOK(t, "CreateVersion in another project", err)
+	})
+
+	t.Run("GetMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		_, err := store.GetVersions(ctx, &versions.VersionId{Id: uuid.New().String()})
+		expectKind(t, "GetVersions", models.KindNotFound, err)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		version := newVersion(uuid.New().String(), 1)
+		err := store.CreateVersion(ctx, version)
+		expectOK(t, "CreateVersion", err)
+
+		updated := proto.Clone(version).(*versions.VersionInfo)
+		updated.Metadata.Version = 2
+		updated.Metadata.Message = "updated"
+		updated.Metadata.UploadedAt = timestamppb.New(uploadTime().Add(time.Hour))
+		revision, err := store.UpdateVersion(ctx, updated, 1, models.VersionFields)
+		expectOK(t, "UpdateVersion", err)
+		expectRevision(t, "UpdateVersion", 2, revision)
+
+		got, err := store.GetVersions(ctx, version.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, updated, got.VersionInfo)
+		expectRevision(t, "GetVersions", 2, got.Revision)
+	})
+
+	t.Run("UpdateFields", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		version := newVersion(uuid.New().String(), 1)
+		err := store.CreateVersion(ctx, version)
+		expectOK(t, "CreateVersion", err)
+
+		// Only the message and the upload time are written, the rest is kept
+		partial := &versions.VersionInfo{
+			Id: version.GetId(),
+			Metadata: &versions.VersionMeta{
+				Version:    7,
+				Message:    "updated",
+				UploadedAt: timestamppb.New(uploadTime().Add(time.Hour)),
+			},
+		}
+		revision, err := store.UpdateVersion(ctx, partial, 1, []string{"message", "uploaded_at"})
+		expectOK(t, "UpdateVersion", err)
+		expectRevision(t, "UpdateVersion", 2, revision)
+
+		want := proto.Clone(version).(*versions.VersionInfo)
+		want.Metadata.Message = "updated"
+		want.Metadata.UploadedAt = partial.GetMetadata().GetUploadedAt()
+		got, err := store.GetVersions(ctx, version.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, want, got.VersionInfo)
+	})
+
+	t.Run("UpdateMissing", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		_, err := store.UpdateVersion(ctx, newVersion(uuid.New().String(), 1), 1, models.VersionFields)
+		expectKind(t, "UpdateVersion", models.KindNotFound, err)
+	})
+
+	t.Run("UpdateStaleRevision", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		version := newVersion(uuid.New().String(), 1)
+		err := store.CreateVersion(ctx, version)
+		expectOK(t, "CreateVersion", err)
+
+		first := proto.Clone(version).(*versions.VersionInfo)
+		first.Metadata.Message = "first writer"
+		_, err = store.UpdateVersion(ctx, first, 1, models.VersionFields)
+		expectOK(t, "UpdateVersion", err)
+
+		second := proto.Clone(version).(*versions.VersionInfo)
+		second.Metadata.Message = "second writer"
+		_, err = store.UpdateVersion(ctx, second, 1, models.VersionFields)
+		expectKind(t, "UpdateVersion at a stale revision", models.KindStale, err)
+		expectRevision(t, "UpdateVersion at a stale revision", 2, staleRevision(err))
+
+		got, err := store.GetVersions(ctx, version.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, first, got.VersionInfo)
+	})
+
+	t.Run("UpdateExistingNumber", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		projectID := uuid.New().String()
+		first, second := newVersion(projectID, 1), newVersion(projectID, 2)
+		for _, version := range []*versions.VersionInfo{first, second} {
+			err := store.CreateVersion(ctx, version)
+			expectOK(t, "CreateVersion", err)
+		}
+
+		updated := proto.Clone(second).(*versions.VersionInfo)
+		updated.Metadata.Version = 1
+		_, err := store.UpdateVersion(ctx, updated, 1, models.VersionFields)
+		expectKind(t, "UpdateVersion to a taken number", models.KindConflict, err)
+
+		got, err := store.GetVersions(ctx, second.GetId())
+		expectOK(t, "GetVersions", err)
+		expectVersion(t, second, got.VersionInfo)
+		expectRevision(t, "GetVersions", 1, got.Revision)
+	})
+
+	t.Run("ListPaging", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		created := map[string]*versions.VersionInfo{}
+		projectID := uuid.New().String()
+		for i := 1; i <= 7; i++ {
+			version := newVersion(projectID, int32(i))
+			err := store.CreateVersion(ctx, version)
+			expectOK(t, "CreateVersion", err)
+			created[version.GetId().GetId()] = version
+		}
+
+		// Page is the number of versions skipped, like an OFFSET
+		seen := map[string]bool{}
+		for page := int32(0); page < 9; page += 3 {
+			got := listVersions(t, store, 3, page)
+			if want := minInt(3, 7-int(page)); len(got) != want {
+				t.Fatalf("ListVersions(count 3, page %d): got %d versions, want %d", page, len(got), want)
+			}
+			for _, version := range got {
+				id := version.GetId().GetId()
+				expectVersion(t, created[id], version.VersionInfo)
+				expectRevision(t, "ListVersions", 1, version.Revision)
+				if seen[id] {
+					t.Fatalf("ListVersions: version %s is on more than one page", id)
+				}
+				seen[id] = true
+			}
+		}
+		if len(seen) != len(created) {
+			t.Fatalf("ListVersions: got %d versions over all pages, want %d", len(seen), len(created))
+		}
+	})
+
+	t.Run("ListAfter", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		for i := 0; i < 7; i++ {
+			err := store.CreateVersion(ctx, newVersion(uuid.New().String(), 1))
+			expectOK(t, "CreateVersion", err)
+		}
+
+		// Each page starts after the last version of the previous one, versions
+		// created meanwhile before it don't shift the next pages
+		var listed []string
+		list := &models.VersionQuery{ListQuery: models.ListQuery{Limit: 3}}
+		for {
+			got := queryVersions(t, store, list)
+			for _, version := range got {
+				listed = append(listed, version.GetId().GetId())
+			}
+			if len(got) < list.Limit {
+				break
+			}
+			list.After = listed[len(listed)-1]
+			before := newVersion(uuid.New().String(), 1)
+			before.Id.Id = fmt.Sprintf("00000000-0000-0000-0000-%012d", len(listed))
+			err := store.CreateVersion(ctx, before)
+			expectOK(t, "CreateVersion", err)
+		}
+		if len(listed) != 7 {
+			t.Fatalf("ListVersions: got %d versions over all pages, want 7", len(listed))
+		}
+		for i := 1; i < len(listed); i++ {
+			if listed[i-1] >= listed[i] {
+				t.Fatalf("ListVersions: versions are not sorted by id, %s is listed before %s", listed[i-1], listed[i])
+			}
+		}
+	})
+
+	t.Run("ListFilter", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		projectID := uuid.New().String()
+		var ids []string
+		for i := 1; i <= 5; i++ {
+			version := newVersion(projectID, int32(i))
+			err := store.CreateVersion(ctx, version)
+			expectOK(t, "CreateVersion", err)
+			ids = append(ids, version.GetId().GetId())
+			err = store.CreateVersion(ctx, newVersion(uuid.New().String(), 1))
+			expectOK(t, "CreateVersion", err)
+		}
+		sort.Strings(ids)
+
+		// Pages are counted over the versions of the project only
+		var listed []string
+		list := &models.VersionQuery{ListQuery: models.ListQuery{Limit: 2}, Filter: models.VersionFilter{ProjectID: projectID}}
+		for {
+			got := queryVersions(t, store, list)
+			for _, version := range got {
+				if version.GetMetadata().GetProjectId() != projectID {
+					t.Fatalf("ListVersions(project %s): got a version of project %s", projectID, version.GetMetadata().GetProjectId())
+				}
+				listed = append(listed, version.GetId().GetId())
+			}
+			if len(got) < list.Limit {
+				break
+			}
+			list.After = listed[len(listed)-1]
+		}
+		if !reflect.DeepEqual(listed, ids) {
+			t.Fatalf("ListVersions(project %s): got %v, want %v", projectID, listed, ids)
+		}
+
+		list = &models.VersionQuery{ListQuery: models.ListQuery{Limit: 10}, Filter: models.VersionFilter{ProjectID: projectID, VersionID: ids[2]}}
+		if got := queryVersions(t, store, list); len(got) != 1 || got[0].GetId().GetId() != ids[2] {
+			t.Fatalf("ListVersions(version %s): got %d versions, want only that one", ids[2], len(got))
+		}
+		list.Filter.ProjectID = uuid.New().String()
+		if got := queryVersions(t, store, list); len(got) != 0 {
+			t.Fatalf("ListVersions(version %s of another project): got %d versions, want none", ids[2], len(got))
+		}
+	})
+
+	t.Run("ListOrder", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		for i := 0; i < 5; i++ {
+			err := store.CreateVersion(ctx, newVersion(uuid.New().String(), 1))
+			expectOK(t, "CreateVersion", err)
+		}
+		first, second := listVersions(t, store, 10, 0), listVersions(t, store, 10, 0)
+		for i := range first {
+			if first[i].GetId().GetId() != second[i].GetId().GetId() {
+				t.Fatalf("ListVersions: the order changed between two calls")
+			}
+		}
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		ctx, store := context.Background(), newStore(t)
+		const writers = 8
+		projectID := uuid.New().String()
+		errs := make(chan error, writers)
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Every writer uploads the same version number of the project
+				errs <- store.CreateVersion(ctx, newVersion(projectID, 1))
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			switch {
+			case err == nil:
+				created++
+			case models.KindOf(err) == models.KindConflict:
+			default:
+				t.Fatalf("concurrent CreateVersion: got %v", err)
+			}
+		}
+		if created != 1 {
+			t.Fatalf("concurrent CreateVersion of one number: %d writers succeeded, want 1", created)
+		}
+	})
+}
+
+func newVersion(projectID string, number int32) *versions.VersionInfo {
+	id := uuid.New().String()
+	return &versions.VersionInfo{
+		Id: &versions.VersionId{Id: id},
+		Metadata: &versions.VersionMeta{
+			Version:    number,
+			ProjectId:  projectID,
+			ObjectName: fmt.Sprintf("%s/%s", projectID, id),
+			Message:    fmt.Sprintf("version %d", number),
+			UploadedAt: timestamppb.New(uploadTime()),
+		},
+	}
+}
+
+// uploadTime is a time every store can keep without losing precision
+func uploadTime() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func expectVersion(t *testing.T, want, got *versions.VersionInfo) {
+	t.Helper()
+	if want == nil {
+		t.Fatalf("got unexpected version %v", got)
+	}
+	if !proto.Equal(want, got) {
+		t.Fatalf("got version %v, want %v", got, want)
+	}
+}
+
+func listVersions(t *testing.T, store service.VersionStore, count, page int32) []*models.Version {
+	t.Helper()
+	return queryVersions(t, store, &models.VersionQuery{ListQuery: models.ListQuery{Limit: int(count), Offset: int(page)}})
+}
+
+func queryVersions(t *testing.T, store service.VersionStore, list *models.VersionQuery) []*models.Version {
+	t.Helper()
+	stream := &versionsStream{}
+	err := store.ListVersions(context.Background(), stream, list)
+	expectOK(t, "ListVersions", err)
+	return stream.sent
+}
+
+// versionsStream keeps what's sent, copied, since repos may reuse messages
+type versionsStream struct {
+	sent []*models.Version
+}
+
+func (s *versionsStream) Send(version *models.Version) error {
+	s.sent = append(s.sent, &models.Version{
+		VersionInfo: proto.Clone(version.VersionInfo).(*versions.VersionInfo),
+		Revision:    version.Revision,
+	})
+	return nil
+}
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// SearchRepo searches the search columns of projects and versions, texts
+// are parsed with websearch_to_tsquery so quotes, "or" and "-" work
+type SearchRepo struct {
+	Pool *pgxpool.Pool
+}
+
+// Search sends a page of hits, ranked with ts_rank_cd then sorted by kind
+// and id. Only the hits of the page are highlighted.
+func (r SearchRepo) Search(ctx context.Context, stream models.SearchStream, search *models.SearchQuery) error {
+	const sql = `WITH q AS (SELECT websearch_to_tsquery('english', $1) AS query),
+								hits AS (
+									SELECT 'project' AS kind, id, id AS project_id, ts_rank_cd(search, query)::float8 AS rank,
+										name, genre, description, '' AS message
+									FROM projects, q
+									WHERE search @@ query AND ($2::uuid IS NULL OR id = $2)
+									UNION ALL
+									SELECT 'version', id, project_id, ts_rank_cd(search, query)::float8,
+										'', '', '', message
+									FROM versions, q
+									WHERE search @@ query AND ($2::uuid IS NULL OR project_id = $2) AND ($3::uuid IS NULL OR id = $3)
+									ORDER BY rank DESC, kind, id LIMIT $4 OFFSET $5
+								)
+								SELECT kind, id, project_id, rank,
+									ts_headline('english', name, query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true'),
+									ts_headline('english', genre, query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', HighlightAll=true'),
+									ts_headline('english', description, query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2'),
+									ts_headline('english', message, query, 'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2')
+								FROM hits, q ORDER BY rank DESC, kind, id`
+
+	var projectID, versionID *string
+	if search.ProjectID != "" {
+		projectID = &search.ProjectID
+	}
+	if search.VersionID != "" {
+		versionID = &search.VersionID
+	}
+
+	rows, err := query(ctx, r.Pool, "SearchRepo.Search", sql,
+		search.Text, projectID, versionID,
+		search.Limit, search.Offset,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		hit := &models.SearchHit{}
+		var name, genre, description, message string
+		err := rows.Scan(
+			&hit.Kind, &hit.ID, &hit.ProjectID, &hit.Rank,
+			&name, &genre, &description, &message,
+		)
+		if err != nil {
+			return err
+		}
+		hit.AddSnippet("name", name)
+		hit.AddSnippet("genre", genre)
+		hit.AddSnippet("description", description)
+		hit.AddSnippet("message", message)
+		if err := stream.Send(hit); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type ShareLinkRepo struct {
+	Pool *pgxpool.Pool
+}
+
+func (r ShareLinkRepo) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	const sql = `INSERT INTO share_links
+								(id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, created_at)
+								VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	var versionID *string
+	if link.VersionID != "" {
+		versionID = &link.VersionID
+	}
+
+	_, err := exec(ctx, r.Pool, "ShareLinkRepo.CreateShareLink", sql,
+		link.ID, link.TokenHash,
+		link.ProjectID, versionID,
+		link.PasswordHash, link.ExpiresAt,
+		link.MaxDownloads, link.CreatedAt,
+	)
+
+	if err != nil {
+		if violated, primaryKey := uniqueViolation(err); violated {
+			if primaryKey {
+				return errShareLinkExists(link.ID)
+			}
+			return errShareLinkTokenExists(link.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r ShareLinkRepo) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	const sql = `SELECT id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, downloads, revoked, created_at
+								FROM share_links WHERE id = $1`
+
+	link, err := scanShareLink(queryRow(ctx, r.Pool, "ShareLinkRepo.GetShareLink", sql, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errShareLinkNotFoundByID(id)
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r ShareLinkRepo) GetShareLinkByToken(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	const sql = `SELECT id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, downloads, revoked, created_at
+								FROM share_links WHERE token_hash = $1`
+
+	link, err := scanShareLink(queryRow(ctx, r.Pool, "ShareLinkRepo.GetShareLinkByToken", sql, tokenHash))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errShareLinkNotFound
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r ShareLinkRepo) RevokeShareLink(ctx context.Context, id string) error {
+	const sql = "UPDATE share_links SET revoked = true WHERE id = $1"
+
+	tag, err := exec(ctx, r.Pool, "ShareLinkRepo.RevokeShareLink", sql, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errShareLinkNotFoundByID(id)
+	}
+
+	return nil
+}
+
+// RegisterShareLinkDownload counts a download, it fails once the link
+// is revoked, expired at the given time or out of downloads
+func (r ShareLinkRepo) RegisterShareLinkDownload(ctx context.Context, id string, now time.Time) error {
+	const sql = `UPDATE share_links SET downloads = downloads + 1
+								WHERE id = $1 AND NOT revoked
+								AND (expires_at IS NULL OR expires_at > $2)
+								AND (max_downloads = 0 OR downloads < max_downloads)`
+
+	tag, err := exec(ctx, r.Pool, "ShareLinkRepo.RegisterShareLinkDownload", sql, id, now)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errShareLinkExhausted(id)
+	}
+
+	return nil
+}
+
+func scanShareLink(row pgx.Row) (*models.ShareLink, error) {
+	link := &models.ShareLink{}
+	var versionID *string
+
+	err := row.Scan(
+		&link.ID, &link.TokenHash,
+		&link.ProjectID, &versionID,
+		&link.PasswordHash, &link.ExpiresAt,
+		&link.MaxDownloads, &link.Downloads,
+		&link.Revoked, &link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if versionID != nil {
+		link.VersionID = *versionID
+	}
+
+	return link, nil
+}
+
+// Local errors
+var (
+	errShareLinkNotFound = &models.Error{
+		Kind:     models.KindNotFound,
+		Resource: "share link",
+		Message:  "share link can not be found",
+	}
+	errShareLinkNotFoundByID = func(id string) error {
+		return models.NotFoundError("share link", id)
+	}
+	errShareLinkExists = func(id string) error {
+		return models.ExistsError("share link", id)
+	}
+	errShareLinkTokenExists = func(id string) error {
+		return models.ConflictError("share link", id, "share link with this token already exists")
+	}
+	errShareLinkExhausted = func(id string) error {
+		return models.PermissionError("share link", id, "share link is revoked, expired or out of downloads")
+	}
+)
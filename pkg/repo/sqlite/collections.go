@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+type CollectionRepo struct {
+	DB *sql.DB
+}
+
+// CreateCollection inserts the collection and its projects in a transaction
+func (r CollectionRepo) CreateCollection(ctx context.Context, collection *models.Collection) error {
+	const query = "INSERT INTO collections (id, name, description, created_at) VALUES (?, ?, ?, ?)"
+
+	return inTx(ctx, r.DB, func(tx *sql.Tx) error {
+		_, err := exec(ctx, tx, "CollectionRepo.CreateCollection", query,
+			collection.ID, collection.Name,
+			collection.Description, collection.CreatedAt.UTC(),
+		)
+		if err != nil {
+			if violated, primaryKey := uniqueViolation(err); violated {
+				if primaryKey {
+					return errCollectionExists(collection.ID)
+				}
+				return errCollectionNameTaken(collection)
+			}
+			return err
+		}
+		return insertCollectionProjects(ctx, tx, collection)
+	})
+}
+
+// UpdateCollection rewrites the collection and replaces its projects in a
+// transaction
+func (r CollectionRepo) UpdateCollection(ctx context.Context, collection *models.Collection) error {
+	const query = "UPDATE collections SET name = ?, description = ? WHERE id = ?"
+	const clear = "DELETE FROM collection_projects WHERE collection_id = ?"
+
+	return inTx(ctx, r.DB, func(tx *sql.Tx) error {
+		result, err := exec(ctx, tx, "CollectionRepo.UpdateCollection", query,
+			collection.Name, collection.Description,
+			collection.ID,
+		)
+		if err != nil {
+			if violated, _ := uniqueViolation(err); violated {
+				return errCollectionNameTaken(collection)
+			}
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected == 0 {
+			return errCollectionNotFoundByID(collection.ID)
+		}
+		if _, err := exec(ctx, tx, "CollectionRepo.ClearCollectionProjects", clear, collection.ID); err != nil {
+			return err
+		}
+		return insertCollectionProjects(ctx, tx, collection)
+	})
+}
+
+// insertCollectionProjects numbers the projects of a collection in their order
+func insertCollectionProjects(ctx context.Context, tx *sql.Tx, collection *models.Collection) error {
+	const query = "INSERT INTO collection_projects (collection_id, project_id, position) VALUES (?, ?, ?)"
+
+	for i, projectID := range collection.ProjectIDs {
+		if _, err := exec(ctx, tx, "CollectionRepo.InsertCollectionProjects", query, collection.ID, projectID, i+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r CollectionRepo) GetCollection(ctx context.Context, id string) (*models.Collection, error) {
+	const query = "SELECT id, name, description, created_at FROM collections WHERE id = ?"
+	const members = "SELECT project_id FROM collection_projects WHERE collection_id = ? ORDER BY position"
+
+	collection := &models.Collection{ProjectIDs: []string{}}
+	err := queryRow(ctx, r.DB, "CollectionRepo.GetCollection", query, id).Scan(
+		&collection.ID, &collection.Name,
+		&collection.Description, &collection.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errCollectionNotFoundByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := queryRows(ctx, r.DB, "CollectionRepo.GetCollectionProjects", members, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var projectID string
+		if err := rows.Scan(&projectID); err != nil {
+			return nil, err
+		}
+		collection.ProjectIDs = append(collection.ProjectIDs, projectID)
+	}
+	return collection, rows.Err()
+}
+
+// ListCollections reads the collections, then the projects of them all. The
+// rows of the first read are closed before the second, the database has
+// one connection.
+func (r CollectionRepo) ListCollections(ctx context.Context) ([]*models.Collection, error) {
+	const query = "SELECT id, name, description, created_at FROM collections ORDER BY name, id"
+	const members = "SELECT collection_id, project_id FROM collection_projects ORDER BY collection_id, position"
+
+	collections, err := r.listCollections(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]*models.Collection{}
+	for _, collection := range collections {
+		byID[collection.ID] = collection
+	}
+
+	rows, err := queryRows(ctx, r.DB, "CollectionRepo.ListCollectionProjects", members)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var collectionID, projectID string
+		if err := rows.Scan(&collectionID, &projectID); err != nil {
+			return nil, err
+		}
+		// A collection created between the two reads isn't listed
+		if collection, ok := byID[collectionID]; ok {
+			collection.ProjectIDs = append(collection.ProjectIDs, projectID)
+		}
+	}
+	return collections, rows.Err()
+}
+
+func (r CollectionRepo) listCollections(ctx context.Context, query string) ([]*models.Collection, error) {
+	rows, err := queryRows(ctx, r.DB, "CollectionRepo.ListCollections", query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*models.Collection{}
+	for rows.Next() {
+		collection := &models.Collection{ProjectIDs: []string{}}
+		err := rows.Scan(
+			&collection.ID, &collection.Name,
+			&collection.Description, &collection.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, collection)
+	}
+	return collections, rows.Err()
+}
+
+func (r CollectionRepo) DeleteCollection(ctx context.Context, id string) error {
+	const query = "DELETE FROM collections WHERE id = ?"
+
+	result, err := exec(ctx, r.DB, "CollectionRepo.DeleteCollection", query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errCollectionNotFoundByID(id)
+	}
+	return nil
+}
+
+// Local errors
+var (
+	errCollectionNotFoundByID = func(id string) error {
+		return models.NotFoundError("collection", id)
+	}
+	errCollectionExists = func(id string) error {
+		return models.ExistsError("collection", id)
+	}
+	errCollectionNameTaken = func(collection *models.Collection) error {
+		return models.ConflictError("collection", collection.ID, "a collection named %q already exists", collection.Name)
+	}
+)
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+type IdempotencyRepo struct {
+	DB *sql.DB
+}
+
+// ReserveIdempotencyKey inserts the record unless its key is taken, or takes
+// over a key whose lease ended. A key released between the insert and the
+// read is inserted again.
+func (r IdempotencyRepo) ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, since time.Time) (*models.IdempotencyRecord, error) {
+	const expire = "DELETE FROM idempotency_keys WHERE created_at < ?"
+	const insert = `INSERT INTO idempotency_keys (scope, key, request_hash, created_at, locked_until)
+								VALUES (?, ?, ?, ?, ?) ON CONFLICT (scope, key) DO UPDATE
+								SET request_hash = excluded.request_hash, created_at = excluded.created_at, locked_until = excluded.locked_until
+								WHERE idempotency_keys.response IS NULL AND idempotency_keys.locked_until <= excluded.created_at`
+	const query = `SELECT request_hash, response, revision, created_at, locked_until
+								FROM idempotency_keys WHERE scope = ? AND key = ?`
+
+	if _, err := exec(ctx, r.DB, "IdempotencyRepo.ExpireIdempotencyKeys", expire, since.UTC()); err != nil {
+		return nil, err
+	}
+	for {
+		result, err := exec(ctx, r.DB, "IdempotencyRepo.ReserveIdempotencyKey", insert,
+			record.Scope, record.Key,
+			record.RequestHash, record.CreatedAt.UTC(),
+			record.LockedUntil.UTC(),
+		)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 1 {
+			return nil, nil
+		}
+
+		previous := &models.IdempotencyRecord{Scope: record.Scope, Key: record.Key}
+		err = queryRow(ctx, r.DB, "IdempotencyRepo.GetIdempotencyKey", query, record.Scope, record.Key).Scan(
+			&previous.RequestHash, &previous.Response,
+			&previous.Revision, &previous.CreatedAt,
+			&previous.LockedUntil,
+		)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return previous, nil
+	}
+}
+
+func (r IdempotencyRepo) CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	const query = `UPDATE idempotency_keys SET response = ?, revision = ?
+								WHERE scope = ? AND key = ? AND locked_until = ?`
+
+	result, err := exec(ctx, r.DB, "IdempotencyRepo.CompleteIdempotencyKey", query,
+		record.Response, record.Revision,
+		record.Scope, record.Key,
+		record.LockedUntil.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errIdempotencyKeyNotFound(record.Key)
+	}
+	return nil
+}
+
+func (r IdempotencyRepo) ReleaseIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error {
+	const query = "DELETE FROM idempotency_keys WHERE scope = ? AND key = ? AND locked_until = ? AND response IS NULL"
+
+	_, err := exec(ctx, r.DB, "IdempotencyRepo.ReleaseIdempotencyKey", query, record.Scope, record.Key, record.LockedUntil.UTC())
+	return err
+}
+
+// Local errors, the same as the postgres ones
+var (
+	errIdempotencyKeyNotFound = func(key string) error {
+		return models.NotFoundError("idempotency key", key)
+	}
+)
@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// listBuilder builds the WHERE, ORDER BY and LIMIT of a page of a list, like
+// the postgres one with ? placeholders
+type listBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (b *listBuilder) where(condition string, args ...interface{}) {
+	b.args = append(b.args, args...)
+	b.conditions = append(b.conditions, condition)
+}
+
+// between bounds a timestamp column by a range, times are written in UTC
+func (b *listBuilder) between(column string, r models.TimeRange) {
+	if !r.From.IsZero() {
+		b.where(column+" >= ?", r.From.UTC())
+	}
+	if !r.To.IsZero() {
+		b.where(column+" <= ?", r.To.UTC())
+	}
+}
+
+// clause is the keyset paginated clause of the page. Items are sorted by the
+// sort expression then by id, or only by id when there's none. value is the
+// expression the sorted field of the cursor is compared as, like "lower(?)".
+func (b *listBuilder) clause(list *models.ListQuery, sort, value string, descending bool) (string, []interface{}) {
+	operator, direction := ">", ""
+	if descending {
+		operator, direction = "<", " DESC"
+	}
+	order := "id" + direction
+	if list.After != "" {
+		if sort == "" {
+			b.where("id "+operator+" ?", list.After)
+		} else {
+			b.where(fmt.Sprintf("(%s, id) %s (%s, ?)", sort, operator, value), list.AfterValue, list.After)
+		}
+	}
+	if sort != "" {
+		order = sort + direction + ", " + order
+	}
+	return b.page(list, order)
+}
+
+// nullsLastClause is the keyset paginated clause of a page sorted by a
+// nullable column then by id. Items without a value come last in either
+// direction, the cursor of one of them has no value.
+func (b *listBuilder) nullsLastClause(list *models.ListQuery, column string, descending bool) (string, []interface{}) {
+	operator, direction := ">", ""
+	if descending {
+		operator, direction = "<", " DESC"
+	}
+	switch {
+	case list.After == "":
+	case list.AfterValue == nil:
+		b.where(fmt.Sprintf("%s IS NULL AND id %s ?", column, operator), list.After)
+	default:
+		b.where(fmt.Sprintf("((%s, id) %s (?, ?) OR %s IS NULL)", column, operator, column), list.AfterValue, list.After)
+	}
+	return b.page(list, column+direction+" NULLS LAST, id"+direction)
+}
+
+// page ends the clause with the conditions, the order and the bounds of the page
+func (b *listBuilder) page(list *models.ListQuery, order string) (string, []interface{}) {
+	var clause string
+	if len(b.conditions) > 0 {
+		clause = " WHERE " + strings.Join(b.conditions, " AND ")
+	}
+	b.args = append(b.args, list.Limit, list.Offset)
+	clause += " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	return clause, b.args
+}
+
+// pageClause is the clause of a page of a list sorted by id
+func pageClause(list *models.ListQuery) (string, []interface{}) {
+	return (&listBuilder{}).clause(list, "", "", false)
+}
+
+// versionClause is the clause of a page of a filtered list of versions
+func versionClause(query *models.VersionQuery) (string, []interface{}) {
+	b := &listBuilder{}
+	if query.Filter.ProjectID != "" {
+		b.where("project_id = ?", query.Filter.ProjectID)
+	}
+	if query.Filter.VersionID != "" {
+		b.where("id = ?", query.Filter.VersionID)
+	}
+	return b.clause(&query.ListQuery, "", "", false)
+}
+
+// projectClause is the clause of a page of a filtered and sorted list of projects
+func projectClause(query *models.ProjectQuery) (string, []interface{}) {
+	b := &listBuilder{}
+	filter := query.Filter
+	if filter.DAW != nil {
+		b.where("daw = ?", filter.DAW.String())
+	}
+	if filter.Genre != "" {
+		b.where("lower(genre) = lower(?)", filter.Genre)
+	}
+	if len(filter.Keys) > 0 {
+		keys := make([]interface{}, len(filter.Keys))
+		for i, key := range filter.Keys {
+			keys[i] = key
+		}
+		b.where("key IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")+")", keys...)
+	}
+	if filter.MinBpm != 0 {
+		b.where("bpm >= ?", filter.MinBpm)
+	}
+	if filter.MaxBpm != 0 {
+		b.where("bpm <= ?", filter.MaxBpm)
+	}
+	if len(filter.BpmRanges) > 0 {
+		ranges := make([]string, len(filter.BpmRanges))
+		var bounds []interface{}
+		for i, r := range filter.BpmRanges {
+			ranges[i] = "bpm BETWEEN ? AND ?"
+			bounds = append(bounds, r.Min, r.Max)
+		}
+		b.where("("+strings.Join(ranges, " OR ")+")", bounds...)
+	}
+	if filter.Public != nil {
+		b.where("public = ?", *filter.Public)
+	}
+	if filter.Template != nil {
+		b.where("template = ?", *filter.Template)
+	}
+	for _, tag := range filter.Tags {
+		b.where("EXISTS (SELECT 1 FROM project_tags WHERE project_tags.project_id = projects.id AND tag = ?)", tag)
+	}
+	if filter.CollectionID != "" {
+		b.where("EXISTS (SELECT 1 FROM collection_projects WHERE collection_projects.project_id = projects.id AND collection_id = ?)", filter.CollectionID)
+	}
+	if filter.ProjectID != "" {
+		b.where("id = ?", filter.ProjectID)
+	}
+	if filter.ExcludeID != "" {
+		b.where("id <> ?", filter.ExcludeID)
+	}
+	b.between("created_at", filter.Created)
+	b.between("updated_at", filter.Updated)
+	b.between("last_version_at", filter.LastVersion)
+
+	switch query.OrderBy {
+	case models.SortByName:
+		return b.clause(&query.ListQuery, "lower(name)", "lower(?)", query.Descending)
+	case models.SortByBpm:
+		return b.clause(&query.ListQuery, "bpm", "?", query.Descending)
+	case models.SortByCreatedAt:
+		return b.clause(&query.ListQuery, "created_at", "?", query.Descending)
+	case models.SortByUpdatedAt:
+		return b.clause(&query.ListQuery, "updated_at", "?", query.Descending)
+	case models.SortByLastVersionAt:
+		return b.nullsLastClause(&query.ListQuery, "last_version_at", query.Descending)
+	}
+	return b.clause(&query.ListQuery, "", "", query.Descending)
+}
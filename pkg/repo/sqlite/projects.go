@@ -0,0 +1,204 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+type ProjectRepo struct {
+	DB *sql.DB
+}
+
+func (r ProjectRepo) CreateProject(ctx context.Context, project *projects.ProjectInfo, now time.Time) error {
+	const query = `INSERT INTO projects
+								(id, name, daw, description, public, bpm, key, genre, created_at, updated_at)
+								VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?9)`
+
+	// Times are kept in UTC, so they compare as text
+	_, err := exec(ctx, r.DB, "ProjectRepo.CreateProject", query,
+		project.GetId().GetId(), project.GetMetadata().GetName(),
+		project.GetMetadata().GetDaw().String(), project.GetMetadata().GetDescription(),
+		project.GetMetadata().GetPublic(), project.GetMetadata().GetBpm(),
+		project.GetMetadata().GetKey(), project.GetMetadata().GetGenre(),
+		now.UTC(),
+	)
+	if err != nil {
+		if violated, _ := uniqueViolation(err); violated {
+			return errProjectExists(project.GetId().GetId())
+		}
+		return err
+	}
+	return nil
+}
+
+func (r ProjectRepo) UpdateProject(ctx context.Context, project *projects.ProjectInfo, revision int64, fields []string, now time.Time) (int64, error) {
+	set, args, err := setColumns(fields, models.ProjectFields, func(field string) interface{} {
+		return models.ProjectValue(project.GetMetadata(), field)
+	})
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("UPDATE projects SET %s, revision=revision+1, updated_at=? WHERE id=? AND revision=? RETURNING revision", set)
+
+	args = append(args, now.UTC(), project.GetId().GetId(), revision)
+	err = queryRow(ctx, r.DB, "ProjectRepo.UpdateProject", query, args...).Scan(&revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, r.currentRevision(ctx, project.GetId().GetId())
+		}
+		return 0, err
+	}
+	return revision, nil
+}
+
+// currentRevision tells why an update didn't match a row, the project is
+// either gone or at another revision
+func (r ProjectRepo) currentRevision(ctx context.Context, id string) error {
+	const query = "SELECT revision FROM projects WHERE id = ?"
+
+	var revision int64
+	err := queryRow(ctx, r.DB, "ProjectRepo.UpdateProject", query, id).Scan(&revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errProjectNotFoundByID(id)
+		}
+		return err
+	}
+	return errProjectRevision(id, revision)
+}
+
+func (r ProjectRepo) GetProject(ctx context.Context, projectID *projects.ProjectId) (*models.Project, error) {
+	const query = `SELECT name, description, public, bpm, key, genre, daw, revision,
+								created_at, updated_at, last_version_at, template FROM projects WHERE id = ?`
+
+	project := &models.Project{ProjectInfo: &projects.ProjectInfo{
+		Id:       &projects.ProjectId{Id: projectID.GetId()},
+		Metadata: &projects.ProjectMeta{},
+	}}
+	var daw string
+
+	err := queryRow(ctx, r.DB, "ProjectRepo.GetProject", query, projectID.GetId()).Scan(
+		&project.Metadata.Name, &project.Metadata.Description, &project.Metadata.Public,
+		&project.Metadata.Bpm, &project.Metadata.Key, &project.Metadata.Genre,
+		&daw, &project.Revision,
+		&project.CreatedAt, &project.UpdatedAt, &project.LastVersionAt,
+		&project.Template,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errProjectNotFoundByID(projectID.GetId())
+		}
+		return nil, err
+	}
+	project.Metadata.Daw = projects.DAW(projects.DAW_value[daw])
+
+	return project, nil
+}
+
+func (r ProjectRepo) MissingProjects(ctx context.Context, ids []string) ([]string, error) {
+	const query = "SELECT value FROM json_each(?) WHERE value NOT IN (SELECT id FROM projects) ORDER BY key"
+
+	// The ids go as a json array, there's no array parameter
+	list, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := queryRows(ctx, r.DB, "ProjectRepo.MissingProjects", query, string(list))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missing := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		missing = append(missing, id)
+	}
+	return missing, rows.Err()
+}
+
+func (r ProjectRepo) DeleteProject(ctx context.Context, projectID *projects.ProjectId) error {
+	const query = "DELETE FROM projects WHERE id = ?"
+
+	result, err := exec(ctx, r.DB, "ProjectRepo.DeleteProject", query, projectID.GetId())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errProjectNotFoundByID(projectID.GetId())
+	}
+	return nil
+}
+
+func (r ProjectRepo) ListProjects(ctx context.Context, stream models.ProjectStream, list *models.ProjectQuery) error {
+	clause, args := projectClause(list)
+	query := "SELECT id, name, description, public, bpm, key, genre, daw, revision, created_at, updated_at, last_version_at, template FROM projects" + clause
+
+	rows, err := queryRows(ctx, r.DB, "ProjectRepo.ListProjects", query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var page []*models.Project
+	for rows.Next() {
+		project := &models.Project{ProjectInfo: &projects.ProjectInfo{
+			Id:       &projects.ProjectId{},
+			Metadata: &projects.ProjectMeta{},
+		}}
+		var daw string
+		err := rows.Scan(
+			&project.Id.Id, &project.Metadata.Name,
+			&project.Metadata.Description, &project.Metadata.Public,
+			&project.Metadata.Bpm, &project.Metadata.Key,
+			&project.Metadata.Genre, &daw,
+			&project.Revision, &project.CreatedAt,
+			&project.UpdatedAt, &project.LastVersionAt,
+			&project.Template,
+		)
+		if err != nil {
+			return err
+		}
+		project.Metadata.Daw = projects.DAW(projects.DAW_value[daw])
+		page = append(page, project)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Sending happens once the rows are closed, a slow client mustn't hold
+	// the only connection
+	rows.Close()
+	for _, project := range page {
+		if err := stream.Send(project); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Local errors, the same as the postgres ones
+var (
+	errProjectNotFoundByID = func(id string) error {
+		return models.NotFoundError("project", id)
+	}
+	errProjectExists = func(id string) error {
+		return models.ExistsError("project", id)
+	}
+	errProjectRevision = func(id string, revision int64) error {
+		return models.StaleError("project", id, revision)
+	}
+)
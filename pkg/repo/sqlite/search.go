@@ -0,0 +1,101 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// SearchRepo searches the fts5 tables that triggers keep in sync with
+// projects and versions. Words are stemmed like the english configuration
+// of postgres, and hits have to match all of them.
+type SearchRepo struct {
+	DB *sql.DB
+}
+
+// Search sends a page of hits, ranked with bm25 then sorted by kind and id.
+// Columns are weighted like the postgres search columns.
+func (r SearchRepo) Search(ctx context.Context, stream models.SearchStream, search *models.SearchQuery) error {
+	const query = `SELECT kind, id, project_id, rank, name, genre, description, message FROM (
+									SELECT 'project' AS kind, id, id AS project_id, -bm25(projects_search, 0, 1.0, 0.4, 0.2) AS rank,
+										highlight(projects_search, 1, char(2), char(3)) AS name,
+										highlight(projects_search, 2, char(2), char(3)) AS genre,
+										snippet(projects_search, 3, char(2), char(3), '…', 32) AS description,
+										'' AS message
+									FROM projects_search
+									WHERE projects_search MATCH ?1 AND (?2 IS NULL OR id = ?2)
+									UNION ALL
+									SELECT 'version', id, project_id, -bm25(versions_search, 0, 0, 0.2),
+										'', '', '', snippet(versions_search, 2, char(2), char(3), '…', 32)
+									FROM versions_search
+									WHERE versions_search MATCH ?1 AND (?2 IS NULL OR project_id = ?2) AND (?3 IS NULL OR id = ?3)
+								) ORDER BY rank DESC, kind, id LIMIT ?4 OFFSET ?5`
+
+	match := matchQuery(search.Text)
+	if match == "" {
+		return nil
+	}
+	var projectID, versionID *string
+	if search.ProjectID != "" {
+		projectID = &search.ProjectID
+	}
+	if search.VersionID != "" {
+		versionID = &search.VersionID
+	}
+
+	rows, err := queryRows(ctx, r.DB, "SearchRepo.Search", query,
+		match, projectID, versionID,
+		search.Limit, search.Offset,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var page []*models.SearchHit
+	for rows.Next() {
+		hit := &models.SearchHit{}
+		var name, genre, description, message string
+		err := rows.Scan(
+			&hit.Kind, &hit.ID, &hit.ProjectID, &hit.Rank,
+			&name, &genre, &description, &message,
+		)
+		if err != nil {
+			return err
+		}
+		hit.AddSnippet("name", name)
+		hit.AddSnippet("genre", genre)
+		hit.AddSnippet("description", description)
+		hit.AddSnippet("message", message)
+		page = append(page, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Sending happens once the rows are closed, a slow client mustn't hold
+	// the only connection
+	rows.Close()
+	for _, hit := range page {
+		if err := stream.Send(hit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchQuery quotes the words of a text, so the fts5 query syntax can't be
+// used to break the statement. Quoted words all have to match.
+func matchQuery(text string) string {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strconv.Quote(word)
+	}
+	return strings.Join(words, " ")
+}
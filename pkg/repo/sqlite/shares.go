@@ -0,0 +1,154 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+type ShareLinkRepo struct {
+	DB *sql.DB
+}
+
+func (r ShareLinkRepo) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	const query = `INSERT INTO share_links
+								(id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, created_at)
+								VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	var versionID *string
+	if link.VersionID != "" {
+		versionID = &link.VersionID
+	}
+	// Times are kept in UTC, so expires_at compares as text
+	var expiresAt *time.Time
+	if link.ExpiresAt != nil {
+		utc := link.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	_, err := exec(ctx, r.DB, "ShareLinkRepo.CreateShareLink", query,
+		link.ID, link.TokenHash,
+		link.ProjectID, versionID,
+		link.PasswordHash, expiresAt,
+		link.MaxDownloads, link.CreatedAt.UTC(),
+	)
+	if err != nil {
+		if violated, primaryKey := uniqueViolation(err); violated {
+			if primaryKey {
+				return errShareLinkExists(link.ID)
+			}
+			return errShareLinkTokenExists(link.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r ShareLinkRepo) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	const query = `SELECT id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, downloads, revoked, created_at
+								FROM share_links WHERE id = ?`
+
+	link, err := scanShareLink(queryRow(ctx, r.DB, "ShareLinkRepo.GetShareLink", query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errShareLinkNotFoundByID(id)
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r ShareLinkRepo) GetShareLinkByToken(ctx context.Context, tokenHash string) (*models.ShareLink, error) {
+	const query = `SELECT id, token_hash, project_id, version_id, password_hash, expires_at, max_downloads, downloads, revoked, created_at
+								FROM share_links WHERE token_hash = ?`
+
+	link, err := scanShareLink(queryRow(ctx, r.DB, "ShareLinkRepo.GetShareLinkByToken", query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errShareLinkNotFound
+		}
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r ShareLinkRepo) RevokeShareLink(ctx context.Context, id string) error {
+	const query = "UPDATE share_links SET revoked = true WHERE id = ?"
+
+	result, err := exec(ctx, r.DB, "ShareLinkRepo.RevokeShareLink", query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errShareLinkNotFoundByID(id)
+	}
+	return nil
+}
+
+// RegisterShareLinkDownload counts a download, it fails once the link
+// is revoked, expired at the given time or out of downloads
+func (r ShareLinkRepo) RegisterShareLinkDownload(ctx context.Context, id string, now time.Time) error {
+	const query = `UPDATE share_links SET downloads = downloads + 1
+								WHERE id = ? AND NOT revoked
+								AND (expires_at IS NULL OR expires_at > ?)
+								AND (max_downloads = 0 OR downloads < max_downloads)`
+
+	result, err := exec(ctx, r.DB, "ShareLinkRepo.RegisterShareLinkDownload", query, id, now.UTC())
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errShareLinkExhausted(id)
+	}
+	return nil
+}
+
+func scanShareLink(row *sql.Row) (*models.ShareLink, error) {
+	link := &models.ShareLink{}
+	var versionID sql.NullString
+
+	err := row.Scan(
+		&link.ID, &link.TokenHash,
+		&link.ProjectID, &versionID,
+		&link.PasswordHash, &link.ExpiresAt,
+		&link.MaxDownloads, &link.Downloads,
+		&link.Revoked, &link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	link.VersionID = versionID.String
+
+	return link, nil
+}
+
+// Local errors, the same as the postgres ones
+var (
+	errShareLinkNotFound = &models.Error{
+		Kind:     models.KindNotFound,
+		Resource: "share link",
+		Message:  "share link can not be found",
+	}
+	errShareLinkNotFoundByID = func(id string) error {
+		return models.NotFoundError("share link", id)
+	}
+	errShareLinkExists = func(id string) error {
+		return models.ExistsError("share link", id)
+	}
+	errShareLinkTokenExists = func(id string) error {
+		return models.ConflictError("share link", id, "share link with this token already exists")
+	}
+	errShareLinkExhausted = func(id string) error {
+		return models.PermissionError("share link", id, "share link is revoked, expired or out of downloads")
+	}
+)
@@ -0,0 +1,92 @@
+// Package sqlite keeps projects, versions and share links in a SQLite
+// database file, for a single user running the server on their own machine
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/droplez/droplez-studio/tools/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// querier runs statements, it's satisfied by databases and transactions
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx runs f in a transaction, it's committed when f succeeds
+func inTx(ctx context.Context, db *sql.DB, f func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Every statement gets its own span, named after the repo method that runs
+// it. Spans cover running the statement, not reading the rows
+
+func exec(ctx context.Context, db querier, name, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatement(ctx, name, query)
+	result, err := db.ExecContext(ctx, query, args...)
+	if err == nil {
+		if affected, err := result.RowsAffected(); err == nil {
+			span.SetAttributes(attribute.Int64("db.rows_affected", affected))
+		}
+	}
+	tracing.End(span, err)
+	return result, err
+}
+
+func queryRows(ctx context.Context, db querier, name, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatement(ctx, name, query)
+	rows, err := db.QueryContext(ctx, query, args...)
+	tracing.End(span, err)
+	return rows, err
+}
+
+func queryRow(ctx context.Context, db querier, name, query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatement(ctx, name, query)
+	row := db.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != sql.ErrNoRows {
+		tracing.End(span, err)
+	} else {
+		tracing.End(span, nil)
+	}
+	return row
+}
+
+func startStatement(ctx context.Context, name, query string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		semconv.DBSystemSqlite,
+		semconv.DBStatementKey.String(query),
+	)
+}
+
+// uniqueViolation reports whether a statement broke a primary key or a
+// unique constraint, and whether it's the primary key
+func uniqueViolation(err error) (violated, primaryKey bool) {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false, false
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return true, true
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		return true, false
+	}
+	return false, false
+}
@@ -0,0 +1,171 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/droplez/droplez-studio/migrations"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/pkg/repo/repotest"
+	"github.com/droplez/droplez-studio/pkg/repo/sqlite"
+	"github.com/droplez/droplez-studio/pkg/service"
+	sqliteClient "github.com/droplez/droplez-studio/third_party/sqlite"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/viper"
+)
+
+func TestMain(m *testing.M) {
+	// The migration scripts are found from the root of the repository
+	if err := os.Chdir("../../.."); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	sqliteClient.Close()
+	os.Exit(code)
+}
+
+// newDB opens a new, migrated database file for a test
+func newDB(t *testing.T) *sql.DB {
+	sqliteClient.Close()
+	viper.Set("sqlite_path", filepath.Join(t.TempDir(), "studio.db"))
+	if err := sqliteClient.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := migrations.MigrateSQLite(); err != nil {
+		t.Fatal(err)
+	}
+	return sqliteClient.DB()
+}
+
+func TestMigrations(t *testing.T) {
+	newDB(t)
+	if err := migrations.CheckSQLite(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNormalizeKeysMigration(t *testing.T) {
+	sqliteClient.Close()
+	viper.Set("sqlite_path", filepath.Join(t.TempDir(), "studio.db"))
+	m, err := migrate.New("file://migrations/sqlite", "sqlite://"+sqliteClient.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	// 7 is the migration normalizing keys
+	if err := m.Migrate(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqliteClient.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	db := sqliteClient.DB()
+
+	keys := []string{"Am", "A minor", "Amin", " a  m ", "F♯m", "f#", "G♭ major", "bb", "Cb", "8a", "8B", "1m", "12D", "DM", "A Minor", "H", "none", ""}
+	id := func(i int) string {
+		return fmt.Sprintf("00000000-0000-0000-0000-%012d", i)
+	}
+	for i, key := range keys {
+		if _, err := db.Exec("INSERT INTO projects (id, name, daw, key) VALUES (?, ?, 'ableton', ?)", id(i), "project "+key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A project written without a key has the default of the column
+	defaulted := id(len(keys))
+	if _, err := db.Exec("INSERT INTO projects (id, name, daw) VALUES (?, 'defaulted', 'ableton')", defaulted); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(7); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range append(keys, "none") {
+		want := ""
+		if parsed, ok := models.ParseKey(key); ok {
+			want = parsed.String()
+		}
+		var got string
+		if err := db.QueryRow("SELECT key FROM projects WHERE id = ?", id(i)).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("key %q: got %q after the migration, want %q", key, got, want)
+		}
+	}
+	if _, err := db.Exec("INSERT INTO projects (id, name, daw) VALUES (?, 'defaulted', 'ableton')", id(len(keys)+1)); err != nil {
+		t.Fatal(err)
+	}
+	var key string
+	if err := db.QueryRow("SELECT key FROM projects WHERE id = ?", id(len(keys)+1)).Scan(&key); err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		t.Errorf("a project written without a key after the migration: got key %q, want none", key)
+	}
+
+	// The projects read back as they're kept can be written back whole
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+	ids := make([]string, len(keys)+2)
+	for i := range ids {
+		ids[i] = id(i)
+	}
+	repotest.RunLegacyProjects(t, sqlite.ProjectRepo{DB: db}, ids...)
+}
+
+func TestProjectRepo(t *testing.T) {
+	repotest.RunProjectStore(t, func(t *testing.T) service.ProjectStore {
+		return sqlite.ProjectRepo{DB: newDB(t)}
+	})
+}
+
+func TestVersionRepo(t *testing.T) {
+	repotest.RunVersionStore(t, func(t *testing.T) service.VersionStore {
+		return sqlite.VersionRepo{DB: newDB(t)}
+	})
+}
+
+func TestShareLinkRepo(t *testing.T) {
+	repotest.RunShareLinkStore(t, func(t *testing.T) service.ShareLinkStore {
+		return sqlite.ShareLinkRepo{DB: newDB(t)}
+	})
+}
+
+func TestIdempotencyRepo(t *testing.T) {
+	repotest.RunIdempotencyStore(t, func(t *testing.T) service.IdempotencyStore {
+		return sqlite.IdempotencyRepo{DB: newDB(t)}
+	})
+}
+
+func TestSearchRepo(t *testing.T) {
+	repotest.RunSearchStore(t, func(t *testing.T) repotest.SearchStores {
+		db := newDB(t)
+		return repotest.SearchStores{Projects: sqlite.ProjectRepo{DB: db}, Versions: sqlite.VersionRepo{DB: db}, Search: sqlite.SearchRepo{DB: db}}
+	})
+}
+
+func TestTagRepo(t *testing.T) {
+	repotest.RunTagStore(t, func(t *testing.T) repotest.TagStores {
+		db := newDB(t)
+		return repotest.TagStores{Projects: sqlite.ProjectRepo{DB: db}, Tags: sqlite.TagRepo{DB: db}}
+	})
+}
+
+func TestCollectionRepo(t *testing.T) {
+	repotest.RunCollectionStore(t, func(t *testing.T) repotest.CollectionStores {
+		db := newDB(t)
+		return repotest.CollectionStores{Projects: sqlite.ProjectRepo{DB: db}, Collections: sqlite.CollectionRepo{DB: db}}
+	})
+}
+
+func TestProjectActivity(t *testing.T) {
+	repotest.RunProjectActivity(t, func(t *testing.T) repotest.ActivityStores {
+		db := newDB(t)
+		return repotest.ActivityStores{Projects: sqlite.ProjectRepo{DB: db}, Versions: sqlite.VersionRepo{DB: db}}
+	})
+}
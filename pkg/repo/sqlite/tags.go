@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+type TagRepo struct {
+	DB *sql.DB
+}
+
+// SetProjectTags replaces the tags of a project in a transaction
+func (r TagRepo) SetProjectTags(ctx context.Context, projectID string, tags []string) error {
+	const clear = "DELETE FROM project_tags WHERE project_id = ?"
+	const insert = "INSERT INTO project_tags (project_id, tag) VALUES (?, ?)"
+
+	return inTx(ctx, r.DB, func(tx *sql.Tx) error {
+		if _, err := exec(ctx, tx, "TagRepo.ClearProjectTags", clear, projectID); err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if _, err := exec(ctx, tx, "TagRepo.SetProjectTags", insert, projectID, tag); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r TagRepo) GetProjectTags(ctx context.Context, projectID string) ([]string, error) {
+	const query = "SELECT tag FROM project_tags WHERE project_id = ? ORDER BY tag"
+
+	rows, err := queryRows(ctx, r.DB, "TagRepo.GetProjectTags", query, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r TagRepo) ListTags(ctx context.Context) ([]*models.TagCount, error) {
+	const query = "SELECT tag, count(*) FROM project_tags GROUP BY tag ORDER BY tag"
+
+	rows, err := queryRows(ctx, r.DB, "TagRepo.ListTags", query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.TagCount{}
+	for rows.Next() {
+		tag := &models.TagCount{}
+		if err := rows.Scan(&tag.Tag, &tag.Projects); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setColumns returns the assignments of an update writing only the given
+// fields and their arguments. Fields become column names, so they must be
+// among the stored ones.
+func setColumns(fields, stored []string, value func(string) interface{}) (string, []interface{}, error) {
+	columns := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields))
+	for _, field := range fields {
+		if !contains(stored, field) {
+			return "", nil, fmt.Errorf("unknown field: %s", field)
+		}
+		columns = append(columns, field+"=?")
+		args = append(args, value(field))
+	}
+	return strings.Join(columns, ", "), args, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
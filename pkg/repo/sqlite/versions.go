@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type VersionRepo struct {
+	DB *sql.DB
+}
+
+func (r VersionRepo) CreateVersion(ctx context.Context, version *versions.VersionInfo) error {
+	const query = "INSERT INTO versions (id, version, project_id, object_name, message, uploaded_at) VALUES (?, ?, ?, ?, ?, ?)"
+
+	_, err := exec(ctx, r.DB, "VersionRepo.CreateVersion", query,
+		version.GetId().GetId(), version.GetMetadata().GetVersion(),
+		version.GetMetadata().GetProjectId(), version.GetMetadata().GetObjectName(),
+		version.GetMetadata().GetMessage(), version.GetMetadata().GetUploadedAt().AsTime(),
+	)
+	if err != nil {
+		if violated, primaryKey := uniqueViolation(err); violated {
+			if primaryKey {
+				return errVersionExists(version.GetId().GetId())
+			}
+			return errVersionNumberExists(version)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r VersionRepo) UpdateVersion(ctx context.Context, version *versions.VersionInfo, revision int64, fields []string) (int64, error) {
+	set, args, err := setColumns(fields, models.VersionFields, func(field string) interface{} {
+		return models.VersionValue(version.GetMetadata(), field)
+	})
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("UPDATE versions SET %s, revision=revision+1 WHERE id=? AND revision=? RETURNING revision", set)
+
+	args = append(args, version.GetId().GetId(), revision)
+	err = queryRow(ctx, r.DB, "VersionRepo.UpdateVersion", query, args...).Scan(&revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, r.currentRevision(ctx, version.GetId().GetId())
+		}
+		if violated, _ := uniqueViolation(err); violated {
+			return 0, errVersionNumberExists(version)
+		}
+		return 0, err
+	}
+	return revision, nil
+}
+
+// currentRevision tells why an update didn't match a row, the version is
+// either gone or at another revision
+func (r VersionRepo) currentRevision(ctx context.Context, id string) error {
+	const query = "SELECT revision FROM versions WHERE id = ?"
+
+	var revision int64
+	err := queryRow(ctx, r.DB, "VersionRepo.UpdateVersion", query, id).Scan(&revision)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errVersionNotFoundByID(id)
+		}
+		return err
+	}
+	return errVersionRevision(id, revision)
+}
+
+func (r VersionRepo) GetVersions(ctx context.Context, in *versions.VersionId) (*models.Version, error) {
+	const query = "SELECT id, version, project_id, object_name, message, uploaded_at, revision FROM versions WHERE id = ?"
+
+	version := &models.Version{VersionInfo: &versions.VersionInfo{
+		Id:       &versions.VersionId{},
+		Metadata: &versions.VersionMeta{},
+	}}
+	var uploadedAt time.Time
+
+	err := queryRow(ctx, r.DB, "VersionRepo.GetVersions", query, in.GetId()).Scan(
+		&version.Id.Id, &version.Metadata.Version,
+		&version.Metadata.ProjectId, &version.Metadata.ObjectName,
+		&version.Metadata.Message, &uploadedAt,
+		&version.Revision,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errVersionNotFoundByID(in.GetId())
+		}
+		return nil, err
+	}
+	version.Metadata.UploadedAt = timestamppb.New(uploadedAt)
+
+	return version, nil
+}
+
+func (r VersionRepo) ListVersions(ctx context.Context, stream models.VersionStream, list *models.VersionQuery) error {
+	clause, args := versionClause(list)
+	query := "SELECT id, version, project_id, object_name, message, uploaded_at, revision FROM versions" + clause
+
+	rows, err := queryRows(ctx, r.DB, "VersionRepo.ListVersions", query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var page []*models.Version
+	for rows.Next() {
+		version := &models.Version{VersionInfo: &versions.VersionInfo{
+			Id:       &versions.VersionId{},
+			Metadata: &versions.VersionMeta{},
+		}}
+		var uploadedAt time.Time
+		err := rows.Scan(
+			&version.Id.Id, &version.Metadata.Version,
+			&version.Metadata.ProjectId, &version.Metadata.ObjectName,
+			&version.Metadata.Message, &uploadedAt,
+			&version.Revision,
+		)
+		if err != nil {
+			return err
+		}
+		version.Metadata.UploadedAt = timestamppb.New(uploadedAt)
+		page = append(page, version)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Sending happens once the rows are closed, a slow client mustn't hold
+	// the only connection
+	rows.Close()
+	for _, version := range page {
+		if err := stream.Send(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Local errors, the same as the postgres ones
+var (
+	errVersionNotFoundByID = func(id string) error {
+		return models.NotFoundError("version", id)
+	}
+	errVersionExists = func(id string) error {
+		return models.ExistsError("version", id)
+	}
+	errVersionNumberExists = func(version *versions.VersionInfo) error {
+		return models.ConflictError("version", version.GetId().GetId(), "project %s already has a version %d",
+			version.GetMetadata().GetProjectId(), version.GetMetadata().GetVersion())
+	}
+	errVersionRevision = func(id string, revision int64) error {
+		return models.StaleError("version", id, revision)
+	}
+)
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type TagRepo struct {
+	Pool *pgxpool.Pool
+}
+
+// SetProjectTags removes the tags that aren't in the list and adds the
+// others, in one statement
+func (r TagRepo) SetProjectTags(ctx context.Context, projectID string, tags []string) error {
+	const sql = `WITH removed AS (DELETE FROM project_tags WHERE project_id = $1 AND NOT (tag = ANY($2)))
+								INSERT INTO project_tags (project_id, tag) SELECT $1, unnest($2::text[])
+								ON CONFLICT (project_id, tag) DO NOTHING`
+
+	_, err := exec(ctx, r.Pool, "TagRepo.SetProjectTags", sql, projectID, tags)
+	return err
+}
+
+func (r TagRepo) GetProjectTags(ctx context.Context, projectID string) ([]string, error) {
+	const sql = "SELECT tag FROM project_tags WHERE project_id = $1 ORDER BY tag"
+
+	rows, err := query(ctx, r.Pool, "TagRepo.GetProjectTags", sql, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r TagRepo) ListTags(ctx context.Context) ([]*models.TagCount, error) {
+	const sql = "SELECT tag, count(*) FROM project_tags GROUP BY tag ORDER BY tag"
+
+	rows, err := query(ctx, r.Pool, "TagRepo.ListTags", sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.TagCount{}
+	for rows.Next() {
+		tag := &models.TagCount{}
+		if err := rows.Scan(&tag.Tag, &tag.Projects); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
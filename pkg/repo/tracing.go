@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/tools/tracing"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// querier runs statements, it's satisfied by pgx connections and pools
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Every statement gets its own span, named after the repo method that runs it
+
+func exec(ctx context.Context, q querier, name, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startStatement(ctx, name, sql)
+	tag, err := q.Exec(ctx, sql, args...)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", tag.RowsAffected()))
+	}
+	tracing.End(span, err)
+	return tag, err
+}
+
+func query(ctx context.Context, q querier, name, sql string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := startStatement(ctx, name, sql)
+	rows, err := q.Query(ctx, sql, args...)
+	if err != nil {
+		tracing.End(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func queryRow(ctx context.Context, q querier, name, sql string, args ...interface{}) pgx.Row {
+	ctx, span := startStatement(ctx, name, sql)
+	return &tracedRow{Row: q.QueryRow(ctx, sql, args...), span: span}
+}
+
+func startStatement(ctx context.Context, name, sql string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		semconv.DBSystemPostgreSQL,
+		semconv.DBStatementKey.String(sql),
+	)
+}
+
+// tracedRows ends the span once the rows are closed
+type tracedRows struct {
+	pgx.Rows
+	span  trace.Span
+	ended bool
+}
+
+func (r *tracedRows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	// Rows close themselves after the last one
+	r.end()
+	return false
+}
+
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	r.end()
+}
+
+func (r *tracedRows) end() {
+	if r.ended {
+		return
+	}
+	r.ended = true
+	tracing.End(r.span, r.Rows.Err())
+}
+
+// tracedRow ends the span once the row is scanned
+type tracedRow struct {
+	pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+	if err == pgx.ErrNoRows {
+		tracing.End(r.span, nil)
+	} else {
+		tracing.End(r.span, err)
+	}
+	return err
+}
@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setColumns returns the assignments of an update writing only the given
+// fields, with placeholders numbered from first, and their arguments. Fields
+// become column names, so they must be among the stored ones.
+func setColumns(fields, stored []string, first int, value func(string) interface{}) (string, []interface{}, error) {
+	columns := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields))
+	for i, field := range fields {
+		if !contains(stored, field) {
+			return "", nil, fmt.Errorf("unknown field: %s", field)
+		}
+		columns = append(columns, fmt.Sprintf("%s=$%d", field, first+i))
+		args = append(args, value(field))
+	}
+	return strings.Join(columns, ", "), args, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -2,142 +2,157 @@ package repo
 
 import (
 	"context"
-	"errors"
+	"fmt"
 	"time"
 
 	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
-	"github.com/droplez/droplez-studio/tools/logger"
-	"github.com/jackc/pgconn"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type VersionRepo struct {
-	Pool *pgxpool.Conn
+	Pool *pgxpool.Pool
 }
 
-func (r VersionRepo) CreateVersion(ctx context.Context, version *versions.VersionInfo) (code codes.Code, err error) {
+func (r VersionRepo) CreateVersion(ctx context.Context, version *versions.VersionInfo) error {
 	const sql = "INSERT INTO versions (id, version, project_id, object_name, message, uploaded_at) VALUES ($1, $2, $3, $4, $5, $6)"
-	log := logger.GetGrpcLogger(ctx)
 
-	_, err = r.Pool.Exec(ctx, sql,
+	_, err := exec(ctx, r.Pool, "VersionRepo.CreateVersion", sql,
 		version.GetId().GetId(), version.GetMetadata().GetVersion(),
 		version.GetMetadata().GetProjectId(), version.GetMetadata().GetObjectName(),
 		version.GetMetadata().Message, version.GetMetadata().GetUploadedAt().AsTime(),
 	)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.UniqueViolation:
-				return codes.AlreadyExists, err
-			default:
-				log.Error(err)
-				return codes.Internal, err
+		if violated, primaryKey := uniqueViolation(err); violated {
+			if primaryKey {
+				return errVersionExists(version.GetId().GetId())
 			}
+			return errVersionNumberExists(version)
 		}
-		log.Error(err)
-		return codes.Internal, err
+		return err
 	}
-	return codes.OK, nil
+	return nil
 }
 
-func (r VersionRepo) UpdateVersion(ctx context.Context, version *versions.VersionInfo) (codes.Code, error) {
-	const sql = "UPDATE versions SET version=$2, project_id=$3, object_name=$4, message=$5, uploaded_at=$6 WHERE id=$1 RETURNING *"
-	log := logger.GetGrpcLogger(ctx)
+func (r VersionRepo) UpdateVersion(ctx context.Context, version *versions.VersionInfo, revision int64, fields []string) (int64, error) {
+	// $1 is the id and $2 the revision, the fields follow
+	set, args, err := setColumns(fields, models.VersionFields, 3, func(field string) interface{} {
+		return models.VersionValue(version.GetMetadata(), field)
+	})
+	if err != nil {
+		return 0, err
+	}
+	sql := fmt.Sprintf("UPDATE versions SET %s, revision=revision+1 WHERE id=$1 AND revision=$2 RETURNING revision", set)
 
-	_, err := r.Pool.Exec(ctx, sql,
-		version.GetId().GetId(), version.GetMetadata().GetVersion(),
-		version.GetMetadata().GetProjectId(), version.GetMetadata().GetObjectName(),
-		version.GetMetadata().Message, version.GetMetadata().GetUploadedAt().AsTime(),
-	)
+	args = append([]interface{}{version.GetId().GetId(), revision}, args...)
+	err = queryRow(ctx, r.Pool, "VersionRepo.UpdateVersion", sql, args...).Scan(&revision)
 
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.UniqueViolation:
-				return codes.AlreadyExists, err
-			default:
-				log.Error(err)
-				return codes.Internal, err
-			}
+		if err == pgx.ErrNoRows {
+			return 0, r.currentRevision(ctx, version.GetId().GetId())
+		}
+		if violated, _ := uniqueViolation(err); violated {
+			return 0, errVersionNumberExists(version)
 		}
-		log.Error(err)
-		return codes.Internal, err
+		return 0, err
 	}
-	return codes.OK, nil
+	return revision, nil
 }
 
-func (r VersionRepo) GetVersions(ctx context.Context, in *versions.VersionId) (*versions.VersionInfo, codes.Code, error) {
-	const sql = "SELECT id, version, project_id, object_name, message, uploaded_at FROM versions WHERE id=$1"
-	var timestamp  time.Time
-	var log = logger.GetGrpcLogger(ctx)
+// currentRevision tells why an update didn't match a row, the version is
+// either gone or at another revision
+func (r VersionRepo) currentRevision(ctx context.Context, id string) error {
+	const sql = "SELECT revision FROM versions WHERE id = $1"
+
+	var revision int64
+	err := queryRow(ctx, r.Pool, "VersionRepo.UpdateVersion", sql, id).Scan(&revision)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return errVersionNotFoundByID(id)
+		}
+		return err
+	}
+	return errVersionRevision(id, revision)
+}
+
+func (r VersionRepo) GetVersions(ctx context.Context, in *versions.VersionId) (*models.Version, error) {
+	const sql = "SELECT id, version, project_id, object_name, message, uploaded_at, revision FROM versions WHERE id=$1"
+	var timestamp time.Time
+	var revision int64
 	version := &versions.VersionInfo{
-		Id: &versions.VersionId{},
+		Id:       &versions.VersionId{},
 		Metadata: &versions.VersionMeta{},
 	}
 
-
-	err := r.Pool.QueryRow(ctx, sql, in.GetId()).Scan(
+	err := queryRow(ctx, r.Pool, "VersionRepo.GetVersions", sql, in.GetId()).Scan(
 		&version.Id.Id, &version.Metadata.Version,
 		&version.Metadata.ProjectId, &version.Metadata.ObjectName,
 		&version.Metadata.Message, &timestamp,
+		&revision,
 	)
 
-	version.Metadata.UploadedAt = &timestamppb.Timestamp{
-		Seconds: timestamp.Unix(),
-		Nanos: timestamppb.Now().Nanos,
-	}
-
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, codes.NotFound, errProjectNotFoundByID(version.GetId().GetId())
-		} else {
-			log.Error(err)
-			return nil, codes.Internal, err
+			return nil, errVersionNotFoundByID(in.GetId())
 		}
+		return nil, err
 	}
+	version.Metadata.UploadedAt = timestamppb.New(timestamp)
 
-	return version, codes.OK, nil
+	return &models.Version{VersionInfo: version, Revision: revision}, nil
 }
 
-func (r VersionRepo) ListVersions(ctx context.Context, stream versions.Versions_ListServer, opt *versions.ListOptions) (codes.Code, error) {
-	const sql = "SELECT id, version, project_id, object_name, message, uploaded_at FROM versions LIMIT $1 OFFSET $2"
-	var timestamp  time.Time
-	var log = logger.GetGrpcLogger(ctx)
+func (r VersionRepo) ListVersions(ctx context.Context, stream models.VersionStream, list *models.VersionQuery) error {
+	clause, args := versionClause(list)
+	sql := "SELECT id, version, project_id, object_name, message, uploaded_at, revision FROM versions" + clause
+	var timestamp time.Time
+	var revision int64
 	version := &versions.VersionInfo{
-		Id: &versions.VersionId{},
+		Id:       &versions.VersionId{},
 		Metadata: &versions.VersionMeta{},
 	}
-	
-	rows, err := r.Pool.Query(ctx, sql, opt.GetPaging().GetCount(), opt.GetPaging().GetPage())
+
+	rows, err := query(ctx, r.Pool, "VersionRepo.ListVersions", sql, args...)
 	if err != nil {
-		log.Error(err)
-		return codes.Internal, err
+		return err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		err = rows.Scan(
 			&version.Id.Id, &version.Metadata.Version,
 			&version.Metadata.ProjectId, &version.Metadata.ObjectName,
-			&version.Metadata.Message, &timestamp,	
+			&version.Metadata.Message, &timestamp,
+			&revision,
 		)
-		version.Metadata.UploadedAt = &timestamppb.Timestamp{
-			Seconds: timestamp.Unix(),
-			Nanos: timestamppb.Now().Nanos,
+		if err != nil {
+			return err
 		}
-	
-	
-		if err := stream.Send(version); err != nil {
-			log.Error(err)
-			return codes.Internal, err
+		version.Metadata.UploadedAt = timestamppb.New(timestamp)
+
+		if err := stream.Send(&models.Version{VersionInfo: version, Revision: revision}); err != nil {
+			return err
 		}
 	}
-	return codes.OK, nil
+	return rows.Err()
+}
 
-}
\ No newline at end of file
+// Local errors
+var (
+	errVersionNotFoundByID = func(id string) error {
+		return models.NotFoundError("version", id)
+	}
+	errVersionExists = func(id string) error {
+		return models.ExistsError("version", id)
+	}
+	errVersionNumberExists = func(version *versions.VersionInfo) error {
+		return models.ConflictError("version", version.GetId().GetId(), "project %s already has a version %d",
+			version.GetMetadata().GetProjectId(), version.GetMetadata().GetVersion())
+	}
+	errVersionRevision = func(id string, revision int64) error {
+		return models.StaleError("version", id, revision)
+	}
+)
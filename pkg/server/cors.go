@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/rs/cors"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+// allowedOrigin checks the origin of browser requests against
+// cors_allowed_origins, a comma separated list where * allows any origin
+func allowedOrigin(origin string) bool {
+	for _, allowed := range strings.Split(viper.GetString("cors_allowed_origins"), ",") {
+		allowed = strings.TrimSpace(allowed)
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// browserHandler lets browsers call grpc services with gRPC-Web, and the
+// other http handlers with CORS
+func browserHandler(grpcServer *grpc.Server, handler http.Handler) http.Handler {
+	grpcWeb := grpcweb.WrapServer(grpcServer,
+		grpcweb.WithOriginFunc(allowedOrigin),
+	)
+	handler = cors.New(cors.Options{
+		AllowOriginFunc:  allowedOrigin,
+		AllowedMethods:   []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete},
+		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"Content-Disposition", "ETag", "Created-At", "Updated-At", "Last-Version-At"},
+		AllowCredentials: true,
+	}).Handler(handler)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if grpcWeb.IsGrpcWebRequest(r) || grpcWeb.IsAcceptableGrpcCorsRequest(r) {
+			grpcWeb.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/api"
+	"github.com/droplez/droplez-studio/pkg/service"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheck probes something the server depends on
+type healthCheck func(ctx context.Context) error
+
+// serviceChecks lists the checks every grpc service depends on, the
+// overall status ("") depends on all of them. Checks that the services
+// don't define are left out.
+var serviceChecks = map[string][]string{
+	"Projects":  {"database", "migrations"},
+	"Versions":  {"database", "migrations"},
+	"Downloads": {"database", "migrations", "storage"},
+}
+
+// healthServer reports the results of the checks through grpc.health.v1
+// and the /healthz and /readyz endpoints
+type healthServer struct {
+	grpc     *health.Server
+	checks   map[string]healthCheck
+	services map[string][]string
+
+	mu      sync.RWMutex
+	results map[string]error
+}
+
+func newHealthServer(grpcServer *grpc.Server, services *service.Services) *healthServer {
+	h := &healthServer{
+		grpc:     health.NewServer(),
+		checks:   map[string]healthCheck{},
+		services: map[string][]string{},
+		results:  map[string]error{},
+	}
+	for name, check := range services.Checks {
+		h.checks[name] = check
+	}
+	for name, checks := range serviceChecks {
+		h.services[api.ServiceName(grpcServer, name)] = checks
+	}
+	// Nothing is serving until the first checks are done
+	h.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	for name := range h.services {
+		h.grpc.SetServingStatus(name, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	healthpb.RegisterHealthServer(grpcServer, h.grpc)
+	return h
+}
+
+// run checks until ctx is done
+func (h *healthServer) run(ctx context.Context) {
+	ticker := time.NewTicker(viper.GetDuration("health_check_interval"))
+	defer ticker.Stop()
+	for {
+		h.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *healthServer) check(ctx context.Context) {
+	log := logger.GetServerLogger()
+	ctx, cancel := context.WithTimeout(ctx, viper.GetDuration("health_check_timeout"))
+	defer cancel()
+
+	results := map[string]error{}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+			err := check(ctx)
+			if err != nil {
+				log.WithField("check", name).Error(err)
+			}
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	h.results = results
+	h.mu.Unlock()
+
+	h.grpc.SetServingStatus("", h.servingStatus(results, nil))
+	for name, checks := range h.services {
+		h.grpc.SetServingStatus(name, h.servingStatus(results, checks))
+	}
+}
+
+// servingStatus is SERVING when the checks passed, all of them when checks is nil
+func (h *healthServer) servingStatus(results map[string]error, checks []string) healthpb.HealthCheckResponse_ServingStatus {
+	if checks == nil {
+		for name := range h.checks {
+			checks = append(checks, name)
+		}
+	}
+	for _, name := range checks {
+		if _, defined := h.checks[name]; !defined {
+			continue
+		}
+		if err, done := results[name]; !done || err != nil {
+			return healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// shutdown reports every service as not serving, so that clients go elsewhere
+func (h *healthServer) shutdown() {
+	h.grpc.Shutdown()
+}
+
+// healthz answers as long as the server runs
+func (h *healthServer) healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// readyz answers with the result of every check, and fails unless all passed
+func (h *healthServer) readyz(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	checks := map[string]string{}
+	for name := range h.checks {
+		err, done := h.results[name]
+		switch {
+		case !done:
+			checks[name] = "pending"
+		case err != nil:
+			checks[name] = err.Error()
+		default:
+			checks[name] = "ok"
+		}
+	}
+
+	code, status := http.StatusOK, "ok"
+	if h.servingStatus(h.results, nil) != healthpb.HealthCheckResponse_SERVING {
+		code, status = http.StatusServiceUnavailable, "unavailable"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	})
+}
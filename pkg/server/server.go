@@ -1,66 +1,198 @@
 package server
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
 	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/droplez/droplez-studio/pkg/api"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/service"
 	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/droplez/droplez-studio/tools/metrics"
+	"github.com/droplez/droplez-studio/tools/tracing"
 
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"github.com/spf13/viper"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/reflection"
 )
 
 // grpc server options
-var opts = []grpc.ServerOption{grpc.MaxRecvMsgSize(2147483648), setupGrpcUnaryOpts(), setupGrpcStreamOpts()}
+var opts = []grpc.ServerOption{grpc.MaxRecvMsgSize(2147483648)}
 
 // grpc server with services
-var grpcServer = func() (grpcServer *grpc.Server) {
-	grpcServer = grpc.NewServer(opts...)
+var grpcServer = func(services *service.Services, extraOpts ...grpc.ServerOption) (grpcServer *grpc.Server) {
+	opts := append([]grpc.ServerOption{setupGrpcUnaryOpts(services), setupGrpcStreamOpts(services)}, opts...)
+	grpcServer = grpc.NewServer(append(opts, extraOpts...)...)
 	// Register services
-	api.RegisterProjectsServer(grpcServer)
-	api.RegisterVersionsServer(grpcServer)
+	api.RegisterProjectsServer(grpcServer, services.Projects)
+	api.RegisterVersionsServer(grpcServer, services.Versions)
+	api.RegisterDownloadsServer(grpcServer, services.Downloads)
+	api.RegisterShareLinksServer(grpcServer, services.ShareLinks)
+	api.RegisterSearchServer(grpcServer, services.Search)
+	api.RegisterTagsServer(grpcServer, services.Tags)
+	api.RegisterCollectionsServer(grpcServer, services.Collections)
 	reflection.Register(grpcServer)
+	metrics.GrpcMetrics.InitializeMetrics(grpcServer)
 	return
 }
 
-// Serve starts grpc server
-func Serve() (err error) {
+// http server for clients that can't use grpc
+var httpServer = func(grpcServer *grpc.Server, services *service.Services, health *healthServer) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", health.healthz)
+	mux.HandleFunc("/readyz", health.readyz)
+	mux.Handle(service.DownloadPath, api.DownloadsHandler(services.Downloads))
+	mux.Handle(api.GatewayPath, api.NewGateway(grpcServer, services, unaryInterceptor(services), streamInterceptor(services)))
+	mux.Handle("/openapi.yaml", api.OpenAPIHandler())
+	mux.Handle("/metrics", metrics.Handler())
+	return &http.Server{
+		Handler:           browserHandler(grpcServer, mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// Serve starts grpc and http servers with the services, until a signal stops them
+func Serve(services *service.Services) (err error) {
 	log := logger.GetServerLogger()
 
-	// Preparing listener
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", "9090")) //TODO: get port from env
+	// Preparing listeners
+	host := viper.GetString("droplez_studio_host")
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, viper.GetString("droplez_studio_port")))
+	if err != nil {
+		return err
+	}
+
+	httpListener, err := net.Listen("tcp", net.JoinHostPort(host, viper.GetString("droplez_studio_http_port")))
 	if err != nil {
 		return err
 	}
 
-	// Start grpc server
-	if err = grpcServer().Serve(listener); err != nil {
+	// Preparing tls, when it's configured
+	var grpcOpts []grpc.ServerOption
+	grpcTLS, err := setupTLS(viper.GetString("tls_client_auth"), "h2")
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if grpcTLS != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(grpcTLS)))
+	}
+	httpTLS, err := setupTLS(httpClientAuth(), "h2", "http/1.1")
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if httpTLS != nil {
+		httpListener = tls.NewListener(httpListener, httpTLS)
+	}
+
+	// Preparing tracing, spans are flushed on shutdown
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
 		log.Error(err)
 		return err
 	}
 
+	// Start grpc and http servers
+	grpcSrv := grpcServer(services, grpcOpts...)
+	health := newHealthServer(grpcSrv, services)
+	httpSrv := httpServer(grpcSrv, services, health)
+	errs := make(chan error, 2)
+	go func() {
+		errs <- grpcSrv.Serve(listener)
+	}()
+	go func() {
+		if err := httpSrv.Serve(httpListener); err != http.ErrServerClosed {
+			errs <- err
+		}
+	}()
+
+	// Wait for a signal, or for one of the servers to fail
+	signals, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go health.run(signals)
+	select {
+	case err = <-errs:
+		log.Error(err)
+	case <-signals.Done():
+		log.Info("shutting down")
+	}
+
 	// Shutdown
-	return nil
+	shutdown(grpcSrv, httpSrv, health, shutdownTracing)
+	return err
+}
+
+// shutdown reports the services as not serving, stops accepting calls and
+// waits for the running ones until shutdown_grace_period is over, then it
+// flushes the spans
+func shutdown(grpcServer *grpc.Server, httpServer *http.Server, health *healthServer, shutdownTracing func(context.Context) error) {
+	log := logger.GetServerLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("shutdown_grace_period"))
+	defer cancel()
+
+	health.shutdown()
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	if err := httpServer.Shutdown(ctx); err != nil {
+		log.Warn("grace period is over, closing http connections")
+		httpServer.Close()
+	}
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		log.Warn("grace period is over, cancelling grpc calls")
+		grpcServer.Stop()
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error(err)
+	}
+	log.Info("server is stopped")
+}
+
+func setupGrpcUnaryOpts(services *service.Services) grpc.ServerOption {
+	return grpc.UnaryInterceptor(unaryInterceptor(services))
+}
+
+func setupGrpcStreamOpts(services *service.Services) grpc.ServerOption {
+	return grpc.StreamInterceptor(streamInterceptor(services))
 }
 
-func setupGrpcUnaryOpts() grpc.ServerOption {
-	return grpc_middleware.WithUnaryServerChain(
+// interceptors are shared by grpc and the gateway
+func unaryInterceptor(services *service.Services) grpc.UnaryServerInterceptor {
+	return grpc_middleware.ChainUnaryServer(
 		grpc_ctxtags.UnaryServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
+		tracing.UnaryServerInterceptor(),
 		grpc_logrus.UnaryServerInterceptor(logger.GrpcLogrusEntry, logger.GrpcLogrusOpts...),
+		metrics.GrpcMetrics.UnaryServerInterceptor(),
+		auth.UnaryServerInterceptor(services.ShareLinks.Resolve),
 		grpc_recovery.UnaryServerInterceptor(),
 	)
 }
 
-func setupGrpcStreamOpts() grpc.ServerOption {
-	return grpc_middleware.WithStreamServerChain(
+func streamInterceptor(services *service.Services) grpc.StreamServerInterceptor {
+	return grpc_middleware.ChainStreamServer(
 		grpc_ctxtags.StreamServerInterceptor(grpc_ctxtags.WithFieldExtractor(grpc_ctxtags.CodeGenRequestFieldExtractor)),
+		tracing.StreamServerInterceptor(),
 		grpc_logrus.StreamServerInterceptor(logger.GrpcLogrusEntry, logger.GrpcLogrusOpts...),
+		metrics.GrpcMetrics.StreamServerInterceptor(),
+		auth.StreamServerInterceptor(services.ShareLinks.Resolve),
 		grpc_recovery.StreamServerInterceptor(),
 	)
 }
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/spf13/viper"
+)
+
+// setupTLS returns the tls config of a listener, verifying client certificates
+// as clientAuth says, or nil when tls_cert_file and tls_key_file are not set
+// and the server runs in plaintext
+func setupTLS(clientAuth string, nextProtos ...string) (*tls.Config, error) {
+	certFile := viper.GetString("tls_cert_file")
+	keyFile := viper.GetString("tls_key_file")
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errTLSKeyPair
+	}
+
+	authType, err := clientAuthType(clientAuth)
+	if err != nil {
+		return nil, err
+	}
+	files := &tlsFiles{
+		certFile:     certFile,
+		keyFile:      keyFile,
+		clientCAFile: viper.GetString("tls_client_ca_file"),
+	}
+	if authType != tls.NoClientCert && files.clientCAFile == "" {
+		return nil, errTLSClientCA
+	}
+	// Fail at startup rather than on the first handshake
+	if err := files.reload(); err != nil {
+		return nil, err
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		ClientAuth: authType,
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		// Every handshake gets the files as they are now
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, clientCAs := files.current()
+			config := base.Clone()
+			config.Certificates = []tls.Certificate{*cert}
+			config.ClientCAs = clientCAs
+			return config, nil
+		},
+	}, nil
+}
+
+// httpClientAuth is the client authentication of the http listener. Probes,
+// metrics scrapers and browsers following download links have no client
+// certificate, so unless tls_http_client_auth is set, certificates are
+// verified when they're given but never required there.
+func httpClientAuth() string {
+	if value := viper.GetString("tls_http_client_auth"); value != "" {
+		return value
+	}
+	if value := viper.GetString("tls_client_auth"); value != "require" {
+		return value
+	}
+	return "optional"
+}
+
+func clientAuthType(value string) (tls.ClientAuthType, error) {
+	switch value {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "optional":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("tls_client_auth must be none, optional or require, got %q", value)
+	}
+}
+
+// minimal time between two checks of the files
+const tlsReloadInterval = 10 * time.Second
+
+// tlsFiles keeps the key pair and the client CAs, and reloads them when the
+// files are rotated
+type tlsFiles struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	modTimes  [3]time.Time
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+func (f *tlsFiles) current() (*tls.Certificate, *x509.CertPool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if time.Since(f.checkedAt) >= tlsReloadInterval {
+		f.checkedAt = time.Now()
+		if err := f.reloadLocked(); err != nil {
+			// Keep serving the previous files until the rotation is complete
+			logger.GetServerLogger().Error(err)
+		}
+	}
+	return f.cert, f.clientCAs
+}
+
+func (f *tlsFiles) reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checkedAt = time.Now()
+	return f.reloadLocked()
+}
+
+func (f *tlsFiles) reloadLocked() error {
+	var modTimes [3]time.Time
+	for i, name := range []string{f.certFile, f.keyFile, f.clientCAFile} {
+		if name == "" {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	if f.cert != nil && modTimes == f.modTimes {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if f.clientCAFile != "" {
+		pem, err := ioutil.ReadFile(f.clientCAFile)
+		if err != nil {
+			return err
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errTLSClientCAFile
+		}
+	}
+
+	if f.cert != nil {
+		logger.GetServerLogger().Info("tls certificates are reloaded")
+	}
+	f.cert, f.clientCAs, f.modTimes = &cert, clientCAs, modTimes
+	return nil
+}
+
+// Local errors
+var (
+	errTLSKeyPair      = errors.New("tls_cert_file and tls_key_file must be set together")
+	errTLSClientCA     = errors.New("tls_client_ca_file must be set to verify client certificates")
+	errTLSClientCAFile = errors.New("tls_client_ca_file doesn't contain any certificate")
+)
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// maxCollectionSize bounds the projects of a collection
+const maxCollectionSize = 1000
+
+// CollectionStore keeps collections, a deleted project leaves the
+// collections it was in. Failures are *models.Error values, any other error
+// is a fault of the store.
+type CollectionStore interface {
+	// CreateCollection fails with a models.KindConflict error when the name is taken
+	CreateCollection(context.Context, *models.Collection) error
+	// UpdateCollection replaces the name, the description and the projects
+	// of a collection
+	UpdateCollection(context.Context, *models.Collection) error
+	GetCollection(ctx context.Context, id string) (*models.Collection, error)
+	// ListCollections returns every collection, sorted by name
+	ListCollections(context.Context) ([]*models.Collection, error)
+	DeleteCollection(ctx context.Context, id string) error
+}
+
+// CollectionService manages collections. They hold projects of any caller,
+// so share links can't read them.
+type CollectionService struct {
+	store    CollectionStore
+	projects ProjectStore
+	now      Clock
+	newID    IDGenerator
+}
+
+func NewCollectionService(store CollectionStore, projects ProjectStore, now Clock, newID IDGenerator) *CollectionService {
+	return &CollectionService{store: store, projects: projects, now: now, newID: newID}
+}
+
+func (s *CollectionService) Create(ctx context.Context, in *models.Collection) (*models.Collection, error) {
+	if err := s.check(ctx, in); err != nil {
+		return nil, err
+	}
+	out := &models.Collection{
+		ID:          s.newID(),
+		Name:        in.Name,
+		Description: in.Description,
+		ProjectIDs:  projectIDs(in),
+		CreatedAt:   s.now().UTC(),
+	}
+	if err := s.store.CreateCollection(ctx, out); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return out, nil
+}
+
+// Update replaces a collection, its projects are sorted in the given order
+func (s *CollectionService) Update(ctx context.Context, in *models.Collection) (*models.Collection, error) {
+	var v violations
+	v.id("id", in.ID)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if err := s.check(ctx, in); err != nil {
+		return nil, err
+	}
+	update := &models.Collection{
+		ID:          in.ID,
+		Name:        in.Name,
+		Description: in.Description,
+		ProjectIDs:  projectIDs(in),
+	}
+	if err := s.store.UpdateCollection(ctx, update); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	out, err := s.store.GetCollection(ctx, in.ID)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return out, nil
+}
+
+func (s *CollectionService) Get(ctx context.Context, id string) (*models.Collection, error) {
+	if err := auth.AuthorizeAll(ctx); err != nil {
+		return nil, err
+	}
+	var v violations
+	v.id("id", id)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	out, err := s.store.GetCollection(ctx, id)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return out, nil
+}
+
+func (s *CollectionService) List(ctx context.Context) ([]*models.Collection, error) {
+	if err := auth.AuthorizeAll(ctx); err != nil {
+		return nil, err
+	}
+	out, err := s.store.ListCollections(ctx)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return out, nil
+}
+
+// Delete a collection, its projects are kept
+func (s *CollectionService) Delete(ctx context.Context, id string) error {
+	if err := auth.AuthorizeWrite(ctx); err != nil {
+		return err
+	}
+	var v violations
+	v.id("id", id)
+	if err := v.err(); err != nil {
+		return err
+	}
+	if err := s.store.DeleteCollection(ctx, id); err != nil {
+		return statusError(ctx, err)
+	}
+	return nil
+}
+
+// check validates a collection to write and makes sure its projects exist
+func (s *CollectionService) check(ctx context.Context, in *models.Collection) error {
+	if err := auth.AuthorizeWrite(ctx); err != nil {
+		return err
+	}
+	var v violations
+	v.text("name", in.Name, true, maxNameLength)
+	v.text("description", in.Description, false, maxTextLength)
+	if len(in.ProjectIDs) > maxCollectionSize {
+		v.add("project_ids", "must be at most %d projects", maxCollectionSize)
+	}
+	seen := map[string]bool{}
+	for i, id := range in.ProjectIDs {
+		field := fmt.Sprintf("project_ids[%d]", i)
+		v.id(field, id)
+		if seen[id] {
+			v.add(field, "project %s is already in the collection", id)
+		}
+		seen[id] = true
+	}
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	// The projects are checked at once, the first missing one is reported
+	missing, err := s.projects.MissingProjects(ctx, in.ProjectIDs)
+	if err != nil {
+		return statusError(ctx, err)
+	}
+	if len(missing) > 0 {
+		return statusError(ctx, errCollectionProjectNotFound(missing[0]))
+	}
+	return nil
+}
+
+// projectIDs are the projects of a collection, an empty collection has none
+// rather than nil ones
+func projectIDs(in *models.Collection) []string {
+	if in.ProjectIDs == nil {
+		return []string{}
+	}
+	return in.ProjectIDs
+}
+
+// Local errors
+var (
+	errCollectionProjectNotFound = func(projectID string) error {
+		return models.NotFoundError("project", projectID)
+	}
+)
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/third_party/storage"
+	"github.com/droplez/droplez-studio/tools/logger"
+)
+
+// Query parameters of a signed download URL
+const (
+	DownloadExpiresParam   = "expires"
+	DownloadLinkParam      = "link"
+	DownloadSignatureParam = "signature"
+)
+
+// DownloadPath is where signed downloads are served, followed by the version id
+const DownloadPath = "/download/"
+
+// DownloadConfig sets how download URLs are signed
+type DownloadConfig struct {
+	// BaseURL is where the http server is reached by clients
+	BaseURL string
+	// TTL is how long an URL can be used
+	TTL time.Duration
+	// SigningKey signs the URLs, a random one is generated when it's empty
+	SigningKey []byte
+}
+
+// DownloadService mints signed download URLs and serves the objects behind them
+type DownloadService struct {
+	versions   *VersionService
+	projects   *ProjectService
+	shareLinks *ShareLinkService
+	blobs      storage.Backend
+	now        Clock
+	config     DownloadConfig
+}
+
+func NewDownloadService(versions *VersionService, projects *ProjectService, shareLinks *ShareLinkService, blobs storage.Backend, now Clock, config DownloadConfig) *DownloadService {
+	if len(config.SigningKey) == 0 {
+		logger.GetServerLogger().Warn("download signing key is not set, download URLs won't survive a restart")
+		config.SigningKey = make([]byte, 32)
+		if _, err := rand.Read(config.SigningKey); err != nil {
+			panic(err)
+		}
+	}
+	return &DownloadService{
+		versions:   versions,
+		projects:   projects,
+		shareLinks: shareLinks,
+		blobs:      blobs,
+		now:        now,
+		config:     config,
+	}
+}
+
+// CreateURL mints a signed, time-limited URL to download the object of a version
+func (s *DownloadService) CreateURL(ctx context.Context, in *versions.VersionId) (string, error) {
+	// Getting the version also authorizes the caller
+	if _, err := s.versions.Get(ctx, in); err != nil {
+		return "", err
+	}
+
+	// Downloads through a share link are counted against it
+	var linkID string
+	if grant := auth.GrantFromContext(ctx); grant != nil {
+		linkID = grant.LinkID
+		if err := s.shareLinks.CheckDownloads(ctx, linkID); err != nil {
+			return "", err
+		}
+	}
+
+	expires := s.now().Add(s.config.TTL).Unix()
+	query := url.Values{}
+	query.Set(DownloadExpiresParam, strconv.FormatInt(expires, 10))
+	if linkID != "" {
+		query.Set(DownloadLinkParam, linkID)
+	}
+	query.Set(DownloadSignatureParam, s.sign(in.GetId(), expires, linkID))
+
+	return fmt.Sprintf("%s%s%s?%s", s.config.BaseURL, DownloadPath, url.PathEscape(in.GetId()), query.Encode()), nil
+}
+
+// Open checks a signed download, and the share link it was minted with,
+// and opens the object of the version. The returned file name is meant
+// for the Content-Disposition header.
+func (s *DownloadService) Open(ctx context.Context, versionID string, query url.Values) (storage.Object, string, error) {
+	expires, err := strconv.ParseInt(query.Get(DownloadExpiresParam), 10, 64)
+	if err != nil {
+		return nil, "", statusError(ctx, errDownloadSignature(versionID))
+	}
+	linkID := query.Get(DownloadLinkParam)
+	expected := s.sign(versionID, expires, linkID)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(DownloadSignatureParam))) {
+		return nil, "", statusError(ctx, errDownloadSignature(versionID))
+	}
+	if s.now().Unix() > expires {
+		return nil, "", statusError(ctx, errDownloadExpired(versionID))
+	}
+	if linkID != "" {
+		if err := s.shareLinks.Check(ctx, linkID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	version, err := s.versions.Get(ctx, &versions.VersionId{Id: versionID})
+	if err != nil {
+		return nil, "", err
+	}
+	project, err := s.projects.Get(ctx, &projects.ProjectId{Id: version.GetMetadata().GetProjectId()})
+	if err != nil {
+		return nil, "", err
+	}
+
+	object, err := s.blobs.Open(ctx, version.GetMetadata().GetObjectName())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			err = models.NotFoundError("object", version.GetMetadata().GetObjectName())
+		}
+		return nil, "", statusError(ctx, err)
+	}
+
+	filename := fmt.Sprintf("%s v%d%s", project.GetMetadata().GetName(), version.GetMetadata().GetVersion(), path.Ext(version.GetMetadata().GetObjectName()))
+	return object, filename, nil
+}
+
+// Count registers a download of an URL checked by Open against the share
+// link it was minted with, if there is one
+func (s *DownloadService) Count(ctx context.Context, query url.Values) error {
+	if linkID := query.Get(DownloadLinkParam); linkID != "" {
+		return s.shareLinks.RegisterDownload(ctx, linkID)
+	}
+	return nil
+}
+
+func (s *DownloadService) sign(versionID string, expires int64, linkID string) string {
+	mac := hmac.New(sha256.New, s.config.SigningKey)
+	fmt.Fprintf(mac, "%s\n%d\n%s", versionID, expires, linkID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Local errors
+var (
+	errDownloadSignature = func(versionID string) error {
+		return models.PermissionError("version", versionID, "download signature is invalid")
+	}
+	errDownloadExpired = func(versionID string) error {
+		return models.PermissionError("version", versionID, "download URL is expired")
+	}
+)
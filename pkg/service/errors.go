@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/runtime/protoiface"
+)
+
+// ErrorDomain is the domain of the ErrorInfo details sent by the services
+const ErrorDomain = "droplez-studio"
+
+// statusError is the one place where errors become the grpc status the
+// caller gets. Domain errors get their code and details, errors that
+// already are a status are kept, and any other error is logged with a
+// correlation id that's the only thing sent to the caller.
+func statusError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var domain *models.Error
+	if errors.As(err, &domain) {
+		return domainStatus(domain)
+	}
+	if errors.Is(err, context.Canceled) {
+		return status.Error(codes.Canceled, err.Error())
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	id := correlationID(ctx)
+	logger.GetGrpcLogger(ctx).WithField("correlation_id", id).Error(err)
+	return withDetails(status.New(codes.Internal, "internal error, correlation id "+id), &errdetails.RequestInfo{RequestId: id})
+}
+
+func domainStatus(err *models.Error) error {
+	resource := &errdetails.ResourceInfo{ResourceType: err.Resource, ResourceName: err.ID, Description: err.Message}
+	switch err.Kind {
+	case models.KindNotFound:
+		return withDetails(status.New(codes.NotFound, err.Message), resource)
+	case models.KindConflict:
+		return withDetails(status.New(codes.AlreadyExists, err.Message), resource)
+	case models.KindStale:
+		return withDetails(status.New(codes.Aborted, err.Message), &errdetails.ErrorInfo{
+			Reason:   RevisionMismatchReason,
+			Domain:   ErrorDomain,
+			Metadata: map[string]string{"revision": strconv.FormatInt(err.Revision, 10)},
+		}, resource)
+	case models.KindPrecondition:
+		return withDetails(status.New(codes.FailedPrecondition, err.Message), &errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{
+				{Type: err.Resource, Subject: err.ID, Description: err.Message},
+			},
+		})
+	case models.KindPermission:
+		return withDetails(status.New(codes.PermissionDenied, err.Message), resource)
+	case models.KindAborted:
+		return withDetails(status.New(codes.Aborted, err.Message), resource)
+	case models.KindUnauthenticated:
+		return withDetails(status.New(codes.Unauthenticated, err.Message), resource)
+	}
+	return status.Error(codes.Unknown, err.Message)
+}
+
+func withDetails(st *status.Status, details ...protoiface.MessageV1) error {
+	detailed, err := st.WithDetails(details...)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// correlationID ties the error sent to a caller to the logs of the call,
+// it's the trace id when the call is traced
+func correlationID(ctx context.Context) string {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	return uuid.New().String()
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ListParams are the options of a list call that don't fit the proto
+// ListOptions, they're sent as metadata
+type ListParams struct {
+	// Cursor is where the list goes on, it's empty for the first page
+	Cursor string
+	// Filter narrows a list of projects, like "daw=ABLETON,bpm>=120,bpm<=128"
+	Filter string
+	// OrderBy sorts a list of projects, like "name" or "bpm desc"
+	OrderBy string
+	// MaxPageSize caps the page below the configured size, like when what's
+	// sent for each item has to fit a trailer. Zero keeps the configured one.
+	MaxPageSize int
+}
+
+// Tempos of projects in harmony are within this many bpm, or a filter's
+// tolerance, of the tempo they're matched with, or of its half or double
+const (
+	defaultBpmTolerance = 4
+	maxBpmTolerance     = 50
+)
+
+// harmony is a filter of the projects that mix well with one, it needs the
+// project to become a models.ProjectFilter
+type harmony struct {
+	projectID string
+	tolerance int32
+}
+
+// filterOperators are tried in order, so "=" doesn't cut ">=" in half
+var filterOperators = []string{">=", "<=", "="}
+
+// projectFilter parses the comma separated conditions of a project filter,
+// what's wrong with them goes to v. A harmonic condition is returned apart.
+func (v *violations) projectFilter(filter string) (models.ProjectFilter, harmony) {
+	var out models.ProjectFilter
+	h := harmony{tolerance: -1}
+	for _, condition := range strings.Split(filter, ",") {
+		if condition = strings.TrimSpace(condition); condition == "" {
+			continue
+		}
+		field, operator, value := splitCondition(condition)
+		if operator == "" {
+			v.add("filter", "%q isn't a condition like field=value", condition)
+			continue
+		}
+		ranged := timeRange(&out, field)
+		if operator != "=" && field != "bpm" && ranged == nil {
+			v.add("filter", "%s can only be compared with =", field)
+			continue
+		}
+		switch field {
+		case "daw":
+			daw, ok := projects.DAW_value[strings.ToUpper(value)]
+			if !ok {
+				v.add("filter", "unknown daw %s", value)
+				continue
+			}
+			d := projects.DAW(daw)
+			out.DAW = &d
+		case "genre":
+			out.Genre = value
+		case "key":
+			key, ok := models.ParseKey(value)
+			if !ok {
+				v.add("filter", "key must be a key like C, F#, Bbm, 8A or 1m")
+				continue
+			}
+			out.Keys = []string{key.String()}
+		case "bpm":
+			bpm, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || bpm < minBpm || bpm > maxBpm {
+				v.add("filter", "bpm must be between %d and %d", minBpm, maxBpm)
+				continue
+			}
+			if operator != "<=" {
+				out.MinBpm = int32(bpm)
+			}
+			if operator != ">=" {
+				out.MaxBpm = int32(bpm)
+			}
+		case "public":
+			public, err := strconv.ParseBool(value)
+			if err != nil {
+				v.add("filter", "public must be true or false")
+				continue
+			}
+			out.Public = &public
+		case "template":
+			template, err := strconv.ParseBool(value)
+			if err != nil {
+				v.add("filter", "template must be true or false")
+				continue
+			}
+			out.Template = &template
+		case "tag":
+			tag := strings.ToLower(value)
+			if v.tag("filter", tag) {
+				out.Tags = append(out.Tags, tag)
+			}
+		case "collection":
+			if _, err := uuid.Parse(value); err != nil {
+				v.add("filter", "collection must be the id of a collection")
+				continue
+			}
+			out.CollectionID = value
+		case "created_at", "updated_at", "last_version_at":
+			at, ok := parseFilterTime(value)
+			if operator == "=" || !ok {
+				v.add("filter", "%s must be compared with >= or <= to a date like 2021-06-30 or a time like 2021-06-30T18:00:00Z", field)
+				continue
+			}
+			if operator == ">=" {
+				ranged.From = at
+			} else {
+				ranged.To = at
+			}
+		case "harmonic":
+			if _, err := uuid.Parse(value); err != nil {
+				v.add("filter", "harmonic must be the id of a project")
+				continue
+			}
+			h.projectID = value
+		case "tolerance":
+			tolerance, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || tolerance < 0 || tolerance > maxBpmTolerance {
+				v.add("filter", "tolerance must be between 0 and %d bpm", maxBpmTolerance)
+				continue
+			}
+			h.tolerance = int32(tolerance)
+		default:
+			v.add("filter", "projects can't be filtered by %s", field)
+		}
+	}
+	if out.MinBpm != 0 && out.MaxBpm != 0 && out.MinBpm > out.MaxBpm {
+		v.add("filter", "the bpm range is empty")
+	}
+	for field, r := range map[string]models.TimeRange{"created_at": out.Created, "updated_at": out.Updated, "last_version_at": out.LastVersion} {
+		if !r.From.IsZero() && !r.To.IsZero() && r.From.After(r.To) {
+			v.add("filter", "the %s range is empty", field)
+		}
+	}
+	switch {
+	case h.projectID == "" && h.tolerance >= 0:
+		v.add("filter", "tolerance only applies to harmonic")
+	case h.projectID != "" && len(out.Keys) > 0:
+		v.add("filter", "harmonic already sets the keys, it can't be used with key")
+	case h.tolerance < 0:
+		h.tolerance = defaultBpmTolerance
+	}
+	return out, h
+}
+
+// harmonize narrows a filter to the projects that mix well with the project
+// of h: in a compatible key, and at a close tempo or at half or double of
+// it. A project without a key or a bpm is only matched by the other.
+func harmonize(filter *models.ProjectFilter, project *models.Project, h harmony) error {
+	meta := project.GetMetadata()
+	key, hasKey := models.ParseKey(meta.GetKey())
+	bpm := meta.GetBpm()
+	if !hasKey && bpm == 0 {
+		return errNothingToHarmonize(h.projectID)
+	}
+	if hasKey {
+		for _, compatible := range key.Compatible() {
+			filter.Keys = append(filter.Keys, compatible.String())
+		}
+	}
+	if bpm != 0 {
+		low, high := bpm-h.tolerance, bpm+h.tolerance
+		filter.BpmRanges = []models.BpmRange{
+			{Min: maxInt32(low, minBpm), Max: high},
+			// Half time, rounded inwards
+			{Min: maxInt32((low+1)/2, minBpm), Max: high / 2},
+			{Min: maxInt32(low*2, minBpm), Max: high * 2},
+		}
+	}
+	filter.ExcludeID = h.projectID
+	return nil
+}
+
+// timeRange is the range of a filter a timestamp field is compared in, it's
+// nil for the other fields
+func timeRange(filter *models.ProjectFilter, field string) *models.TimeRange {
+	switch field {
+	case "created_at":
+		return &filter.Created
+	case "updated_at":
+		return &filter.Updated
+	case "last_version_at":
+		return &filter.LastVersion
+	}
+	return nil
+}
+
+// parseFilterTime reads an RFC 3339 time, or a date that stands for its
+// midnight in UTC
+func parseFilterTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+		if at, err := time.Parse(layout, value); err == nil {
+			return at.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Local errors
+var (
+	errNothingToHarmonize = func(id string) error {
+		return models.PreconditionError("project", id, "project %s has neither a key nor a bpm to match", id)
+	}
+)
+
+func splitCondition(condition string) (field, operator, value string) {
+	for _, operator := range filterOperators {
+		if i := strings.Index(condition, operator); i > 0 {
+			return strings.TrimSpace(condition[:i]), operator, strings.TrimSpace(condition[i+len(operator):])
+		}
+	}
+	return "", "", ""
+}
+
+// projectOrder parses the order of a project list, a field optionally
+// followed by "asc" or "desc"
+func (v *violations) projectOrder(order string) (field string, descending bool) {
+	words := strings.Fields(order)
+	if len(words) == 0 {
+		return "", false
+	}
+	switch words[0] {
+	case models.SortByName, models.SortByBpm, models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByLastVersionAt:
+		field = words[0]
+	default:
+		v.add("order_by", "projects can't be sorted by %s", words[0])
+	}
+	if len(words) > 2 || len(words) == 2 && words[1] != "asc" && words[1] != "desc" {
+		v.add("order_by", "must be a field optionally followed by asc or desc")
+		return field, false
+	}
+	return field, len(words) == 2 && words[1] == "desc"
+}
+
+// sortValue is the sorted field of a project in a cursor, as the store
+// compares it. Cursors are JSON, so numbers come back as float64 and times
+// as RFC 3339 strings. A project without versions has no last version time.
+func sortValue(field string, value interface{}) (interface{}, bool) {
+	switch field {
+	case models.SortByName:
+		name, ok := value.(string)
+		return name, ok
+	case models.SortByBpm:
+		bpm, ok := value.(float64)
+		return int32(bpm), ok
+	case models.SortByCreatedAt, models.SortByUpdatedAt, models.SortByLastVersionAt:
+		if value == nil && field == models.SortByLastVersionAt {
+			return nil, true
+		}
+		text, ok := value.(string)
+		if !ok {
+			return nil, false
+		}
+		at, err := time.Parse(time.RFC3339Nano, text)
+		return at.UTC(), err == nil
+	}
+	return nil, true
+}
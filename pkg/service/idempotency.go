@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/tools/logger"
+	"google.golang.org/protobuf/proto"
+)
+
+// IdempotencyStore keeps the calls made with an idempotency key. Keys are
+// unique within their scope.
+type IdempotencyStore interface {
+	// ReserveIdempotencyKey saves a record without a response, unless its key
+	// was used after since. The record of that call is returned then, and
+	// older records are forgotten. A key whose call isn't completed and was
+	// locked until the creation of the record, or before, is taken over.
+	ReserveIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord, since time.Time) (*models.IdempotencyRecord, error)
+	// CompleteIdempotencyKey saves the response of a key reserved by the
+	// record, it fails with a models.KindNotFound error once it's taken over
+	CompleteIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey forgets a key reserved by the record, so a failed
+	// call can be retried
+	ReleaseIdempotencyKey(ctx context.Context, record *models.IdempotencyRecord) error
+}
+
+// maxIdempotencyKeyLength is enough for uuids and the other keys clients use
+const maxIdempotencyKeyLength = 255
+
+// Idempotency makes creations safe to retry: a call sent again with the same
+// key gets the response of the first one, as long as it's within the window.
+// A call holds its key for the lease, a retry takes it over afterwards when
+// the call died before it completed.
+type Idempotency struct {
+	store  IdempotencyStore
+	window time.Duration
+	lease  time.Duration
+	now    Clock
+}
+
+func NewIdempotency(store IdempotencyStore, window, lease time.Duration, now Clock) *Idempotency {
+	return &Idempotency{store: store, window: window, lease: lease, now: now}
+}
+
+// run calls create once per key with the time of the call, create fills
+// response and returns the revision of what it created. A retry gets the
+// stored response unmarshaled into response instead, with the revision and
+// the time of the first call, and a key sent with another request fails with
+// codes.FailedPrecondition. Without a key create is just called.
+func (i *Idempotency) run(ctx context.Context, method, key string, request, response proto.Message, create func(now time.Time) (int64, error)) (int64, time.Time, error) {
+	// Stores keep microseconds, the lease and what's created at this time
+	// must read back as they're written
+	now := i.now().UTC().Truncate(time.Microsecond)
+	if key == "" {
+		revision, err := create(now)
+		return revision, now, err
+	}
+	var v violations
+	v.text("idempotency_key", key, true, maxIdempotencyKeyLength)
+	if err := v.err(); err != nil {
+		return 0, time.Time{}, err
+	}
+	hash, err := requestHash(request)
+	if err != nil {
+		return 0, time.Time{}, statusError(ctx, err)
+	}
+
+	record := &models.IdempotencyRecord{
+		Scope:       idempotencyScope(ctx, method),
+		Key:         key,
+		RequestHash: hash,
+		CreatedAt:   now,
+		LockedUntil: now.Add(i.lease),
+	}
+	previous, err := i.store.ReserveIdempotencyKey(ctx, record, record.CreatedAt.Add(-i.window))
+	if err != nil {
+		return 0, time.Time{}, statusError(ctx, err)
+	}
+	if previous != nil {
+		revision, err := replay(ctx, previous, record, response)
+		return revision, previous.CreatedAt, err
+	}
+
+	revision, err := create(now)
+	if err != nil {
+		if releaseErr := i.store.ReleaseIdempotencyKey(ctx, record); releaseErr != nil {
+			logger.GetGrpcLogger(ctx).WithError(releaseErr).Warnf("idempotency key %s can't be released, it's taken until its lease ends", key)
+		}
+		return 0, time.Time{}, err
+	}
+
+	// The resource is created, when its response can't be saved a retry
+	// made after the lease creates it again
+	record.Revision = revision
+	if record.Response, err = proto.Marshal(response); err == nil {
+		err = i.store.CompleteIdempotencyKey(ctx, record)
+	}
+	if err != nil {
+		logger.GetGrpcLogger(ctx).WithError(err).Errorf("response of idempotency key %s can't be saved", key)
+	}
+	return revision, now, nil
+}
+
+// replay returns the response of a previous call made with the key
+func replay(ctx context.Context, previous, record *models.IdempotencyRecord, response proto.Message) (int64, error) {
+	if previous.RequestHash != record.RequestHash {
+		return 0, statusError(ctx, errIdempotencyKeyReused(record.Key))
+	}
+	if !previous.Completed() {
+		return 0, statusError(ctx, errIdempotencyKeyInProgress(record.Key))
+	}
+	if err := proto.Unmarshal(previous.Response, response); err != nil {
+		return 0, statusError(ctx, err)
+	}
+	return previous.Revision, nil
+}
+
+// idempotencyScope keeps keys of different methods and callers apart.
+// Callers are told apart by their client certificates, the ones without one
+// share the scope of the method, so their keys must be unique among them.
+func idempotencyScope(ctx context.Context, method string) string {
+	if identity := auth.IdentityFromContext(ctx); identity != nil {
+		return method + ":" + identity.CommonName + ":" + strings.Join(identity.Organization, ",")
+	}
+	return method
+}
+
+// requestHash tells requests apart, the marshaling is deterministic so the
+// same request always has the same hash
+func requestHash(request proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Local errors
+var (
+	errIdempotencyKeyInProgress = func(key string) error {
+		return models.AbortedError("idempotency key", key, "a call with this idempotency key is in progress, retry it later")
+	}
+	errIdempotencyKeyReused = func(key string) error {
+		return models.PreconditionError("idempotency key", key, "idempotency key %s was sent with another request", key)
+	}
+)
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// maskedFields returns the metadata fields an update mask selects, in the
+// order of mutable. Paths are relative to the updated message, like
+// "metadata.name", and "metadata" selects every mutable field.
+func maskedFields(mask *fieldmaskpb.FieldMask, mutable []string) ([]string, error) {
+	var v violations
+	if len(mask.GetPaths()) == 0 {
+		v.add("update_mask", "has no fields")
+		return nil, v.err()
+	}
+
+	selected := map[string]bool{}
+	for _, path := range mask.GetPaths() {
+		if path == "metadata" {
+			for _, field := range mutable {
+				selected[field] = true
+			}
+			continue
+		}
+		field := strings.TrimPrefix(path, "metadata.")
+		if field == path || !contains(mutable, field) {
+			v.add("update_mask", "field can't be updated: %s", path)
+			continue
+		}
+		selected[field] = true
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	for _, field := range mutable {
+		if selected[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
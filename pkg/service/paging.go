@@ -0,0 +1,134 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/droplez/droplez-go-proto/pkg/common"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/droplez/droplez-studio/tools/logger"
+)
+
+// Page sizes of list calls
+const (
+	defaultPageSize    = 100
+	defaultMaxPageSize = 1000
+)
+
+// ListConfig is the paging of list calls
+type ListConfig struct {
+	// CursorKey signs the cursors, a random one is generated when it's empty
+	CursorKey []byte
+	// MaxPageSize caps the count of a page, larger counts are lowered to it
+	MaxPageSize int
+}
+
+// Pager turns the paging of list calls into store queries, and the last item
+// of a page into the cursor of the next one. Cursors are signed, so callers
+// can't forge one.
+type Pager struct {
+	config ListConfig
+}
+
+func NewPager(config ListConfig) *Pager {
+	if len(config.CursorKey) == 0 {
+		logger.GetServerLogger().Warn("cursor signing key is not set, list cursors won't survive a restart")
+		config.CursorKey = make([]byte, 32)
+		if _, err := rand.Read(config.CursorKey); err != nil {
+			panic(err)
+		}
+	}
+	if config.MaxPageSize <= 0 {
+		config.MaxPageSize = defaultMaxPageSize
+	}
+	return &Pager{config: config}
+}
+
+// cursor is where a list goes on, list tells lists apart so a cursor of
+// projects can't be used to list versions, or projects in another order.
+// Value is the sorted field of the item After, if the list is sorted by one.
+type cursor struct {
+	List  string      `json:"l"`
+	After string      `json:"a"`
+	Value interface{} `json:"v,omitempty"`
+}
+
+// query is the store query of a page, what's wrong with the paging or the
+// cursor goes to v. The store is asked for one more item than the page
+// holds, to tell whether there's a next one.
+func (p *Pager) query(v *violations, list string, paging *common.Paging, params ListParams) *models.ListQuery {
+	v.paging("paging", paging)
+	max := p.config.MaxPageSize
+	if params.MaxPageSize > 0 && params.MaxPageSize < max {
+		max = params.MaxPageSize
+	}
+	size := int(paging.GetCount())
+	switch {
+	case size == 0:
+		size = defaultPageSize
+		if size > max {
+			size = max
+		}
+	case size > max:
+		size = max
+	}
+	// Pages go on with cursors, skipping items shifts them while the list changes
+	if paging.GetPage() > 0 {
+		v.add("paging.page", "isn't supported, pages go on with cursors")
+	}
+	query := &models.ListQuery{Limit: size + 1}
+	token := params.Cursor
+	if token == "" {
+		return query
+	}
+	c, ok := p.decode(token)
+	if !ok || c.List != list {
+		v.add("cursor", "is not a cursor of this list")
+		return query
+	}
+	query.After, query.AfterValue = c.After, c.Value
+	return query
+}
+
+// next is the cursor of the page after the one ending with the item last,
+// whose sorted field is value. It's empty on the last page.
+func (p *Pager) next(list string, more bool, last string, value interface{}) string {
+	if !more {
+		return ""
+	}
+	// Values are strings and numbers, they always marshal
+	payload, _ := json.Marshal(cursor{List: list, After: last, Value: value})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(p.sign(encoded))
+}
+
+func (p *Pager) decode(token string) (*cursor, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, false
+	}
+	encoded := parts[0]
+	mac, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(mac, p.sign(encoded)) {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, false
+	}
+	c := &cursor{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, false
+	}
+	return c, true
+}
+
+func (p *Pager) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, p.config.CursorKey)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
@@ -2,110 +2,237 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/droplez/droplez-go-proto/pkg/common"
 	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
-	"github.com/droplez/droplez-studio/pkg/repo"
-	"github.com/droplez/droplez-studio/third_party/postgres"
-	"github.com/google/uuid"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 )
 
+// ProjectStore keeps projects, they're created at revision 1. Stores keep
+// the timestamps of projects: created_at and updated_at are the times the
+// service gives them, and last_version_at follows the versions of the
+// VersionStore.
+// Failures are *models.Error values, any other error is a fault of the store.
 type ProjectStore interface {
-	CreateProject(context.Context, *projects.ProjectInfo) (codes.Code, error)
-	UpdateProject(context.Context, *projects.ProjectInfo) (codes.Code, error)
-	GetProject(context.Context, *projects.ProjectId) (*projects.ProjectInfo, codes.Code, error)
-	DeleteProject(context.Context, *projects.ProjectId) (codes.Code, error)
-	ListProjects(context.Context, projects.Projects_ListServer, *projects.ListOptions) (codes.Code, error)
+	// CreateProject stores a new project, created and updated at the given time
+	CreateProject(context.Context, *projects.ProjectInfo, time.Time) error
+	// UpdateProject writes the given metadata fields of a project if it's still
+	// at the given revision and returns the new one, it fails with a
+	// models.KindStale error holding the current one otherwise. updated_at is
+	// set to the given time of the update.
+	UpdateProject(context.Context, *projects.ProjectInfo, int64, []string, time.Time) (int64, error)
+	GetProject(context.Context, *projects.ProjectId) (*models.Project, error)
+	// MissingProjects returns the ids that aren't ids of projects, in the
+	// order they're given, with one query
+	MissingProjects(ctx context.Context, ids []string) ([]string, error)
+	DeleteProject(context.Context, *projects.ProjectId) error
+	// ListProjects sends a page of the projects passing the filter of the
+	// query, sorted by its field then by id
+	ListProjects(context.Context, models.ProjectStream, *models.ProjectQuery) error
 }
 
-var projectStore ProjectStore
+// projectPage sends the projects of a page, and keeps the last one for the
+// cursor. The one project the store lists past the page isn't sent.
+type projectPage struct {
+	models.ProjectStream
+	orderBy   string
+	size      int
+	sent      int
+	last      string
+	lastValue interface{}
+	more      bool
+}
 
-var initProjectRepo = func(ctx context.Context) ProjectStore {
-	if projectStore == nil {
-		projectStore = repo.ProjectRepo{
-			Pool: postgres.Pool(ctx),
-		}
+func (p *projectPage) Send(project *models.Project) error {
+	if p.sent == p.size {
+		p.more = true
+		return nil
+	}
+	p.sent++
+	p.last = project.GetId().GetId()
+	if p.orderBy != "" {
+		p.lastValue = project.SortValue(p.orderBy)
 	}
-	return projectStore
+	return p.ProjectStream.Send(project)
 }
 
-// ProjectCreate a new project
-func ProjectCreate(ctx context.Context, in *projects.ProjectMeta) (out *projects.ProjectInfo, err error) {
-	// Prepare repo layer
-	repo := initProjectRepo(ctx)
+// ProjectService manages projects
+type ProjectService struct {
+	store       ProjectStore
+	idempotency *Idempotency
+	pager       *Pager
+	now         Clock
+	newID       IDGenerator
+}
 
-	// Create new project
-	out = &projects.ProjectInfo{
-		Metadata: in,
-		Id: &projects.ProjectId{
-			Id: uuid.New().String(),
-		},
-	}
+func NewProjectService(store ProjectStore, idempotency *Idempotency, pager *Pager, now Clock, newID IDGenerator) *ProjectService {
+	return &ProjectService{store: store, idempotency: idempotency, pager: pager, now: now, newID: newID}
+}
 
-	code, err := repo.CreateProject(ctx, out)
+// Create a new project, a retry with the same idempotency key gets the
+// project created by the first call
+func (s *ProjectService) Create(ctx context.Context, in *projects.ProjectMeta, idempotencyKey string) (*models.Project, error) {
+	var v violations
+	v.projectMeta("", in, models.ProjectFields)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	normalizeKey(in)
+
+	out := &projects.ProjectInfo{}
+	revision, createdAt, err := s.idempotency.run(ctx, "projects.Create", idempotencyKey, in, out, func(now time.Time) (int64, error) {
+		// Create new project
+		out.Metadata = in
+		out.Id = &projects.ProjectId{
+			Id: s.newID(),
+		}
+		if err := s.store.CreateProject(ctx, out, now); err != nil {
+			return 0, statusError(ctx, err)
+		}
+		return 1, nil
+	})
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, err
 	}
 
-	return
+	// The project is created at the time of the call, a retry gets it as the
+	// first call returned it
+	return &models.Project{ProjectInfo: out, Revision: revision, CreatedAt: createdAt, UpdatedAt: createdAt}, nil
 }
 
-// ProjectUpdate a project
-func ProjectUpdate(ctx context.Context, in *projects.ProjectInfo) (*projects.ProjectInfo, error) {
-	// Prepare repo layer
-	repo := initProjectRepo(ctx)
+// Update a project, it must still be at the revision the caller read. Only
+// the fields of the mask are written when there's one.
+func (s *ProjectService) Update(ctx context.Context, in *projects.ProjectInfo, revision int64, mask *fieldmaskpb.FieldMask) (*models.Project, error) {
+	if revision <= 0 {
+		return nil, statusError(ctx, errRevisionRequired("project", in.GetId().GetId()))
+	}
+	fields := models.ProjectFields
+	if mask != nil {
+		var err error
+		if fields, err = maskedFields(mask, models.ProjectFields); err != nil {
+			return nil, err
+		}
+	}
+	var v violations
+	v.id("id.id", in.GetId().GetId())
+	v.projectMeta("metadata.", in.GetMetadata(), fields)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	normalizeKey(in.GetMetadata())
 
 	// Update project
-	code, err := repo.UpdateProject(ctx, in)
-	if err != nil {
-		return nil, status.Error(code, err.Error())
+	if _, err := s.store.UpdateProject(ctx, in, revision, fields, s.now().UTC()); err != nil {
+		return nil, statusError(ctx, err)
 	}
 
-	return in, nil
+	// The project is read back, with the fields a mask left and the
+	// timestamps it keeps
+	out, err := s.store.GetProject(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return out, nil
 }
 
-func ProjectGet(ctx context.Context, in *projects.ProjectId) (*projects.ProjectInfo, error) {
-	// Prepare repo layer
-	repo := initProjectRepo(ctx)
+func (s *ProjectService) Get(ctx context.Context, in *projects.ProjectId) (*models.Project, error) {
+	var v violations
+	v.id("id", in.GetId())
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	if err := auth.AuthorizeProject(ctx, in.GetId()); err != nil {
+		return nil, err
+	}
 
 	// Update project
-	project, code, err := repo.GetProject(ctx, in)
+	project, err := s.store.GetProject(ctx, in)
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(ctx, err)
 	}
 
 	return project, nil
 }
 
-// Update application
-func ProjectDelete(ctx context.Context, in *projects.ProjectInfo) (*common.EmptyMessage, error) {
-	repo := initProjectRepo(ctx)
+// Delete a project, its name must be given too
+func (s *ProjectService) Delete(ctx context.Context, in *projects.ProjectInfo) (*common.EmptyMessage, error) {
+	var v violations
+	v.id("id.id", in.GetId().GetId())
+	v.text("metadata.name", in.GetMetadata().GetName(), true, maxNameLength)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
 	projectID := &projects.ProjectId{
 		Id: in.GetId().GetId(),
 	}
-	projectGotten, code, err := repo.GetProject(ctx, projectID)
+	projectGotten, err := s.store.GetProject(ctx, projectID)
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(ctx, err)
 	}
 
 	if projectGotten.Metadata.Name == in.Metadata.Name {
-		code, err := repo.DeleteProject(ctx, projectID)
-		if err != nil {
-			return nil, status.Error(code, err.Error())
+		if err := s.store.DeleteProject(ctx, projectID); err != nil {
+			return nil, statusError(ctx, err)
 		}
 		return &common.EmptyMessage{}, nil
 	}
 
-	return nil, status.Error(codes.NotFound, "Project with this ID has another Name")
+	return nil, statusError(ctx, errProjectNameMismatch(projectID.GetId()))
 }
 
-func ProjectsList(ctx context.Context, stream projects.Projects_ListServer, options *projects.ListOptions) error {
-	repo := initProjectRepo(ctx)
-	code, err := repo.ListProjects(ctx, stream, options)
-	if err != nil {
-		return status.Error(code, err.Error())
+// List sends a page of the projects passing the filter, the next one starts
+// at the returned cursor. There's no cursor after the last page.
+func (s *ProjectService) List(ctx context.Context, stream models.ProjectStream, options *projects.ListOptions, params ListParams) (string, error) {
+	var v violations
+	filter, h := v.projectFilter(params.Filter)
+	query := &models.ProjectQuery{Filter: filter}
+	query.OrderBy, query.Descending = v.projectOrder(params.OrderBy)
+	// A cursor only goes on with the same filter and order
+	list := "projects?" + params.Filter + "&" + params.OrderBy
+	query.ListQuery = *s.pager.query(&v, list, options.GetPaging(), params)
+	if query.After != "" {
+		var ok bool
+		if query.AfterValue, ok = sortValue(query.OrderBy, query.AfterValue); !ok {
+			v.add("cursor", "is not a cursor of this list")
+		}
+	}
+	if err := v.err(); err != nil {
+		return "", err
+	}
+	if h.projectID != "" {
+		project, err := s.Get(ctx, &projects.ProjectId{Id: h.projectID})
+		if err != nil {
+			return "", err
+		}
+		if err := harmonize(&query.Filter, project, h); err != nil {
+			return "", statusError(ctx, err)
+		}
+	}
+
+	// A share link only ever lists the shared project
+	if grant := auth.GrantFromContext(ctx); grant != nil {
+		query.Filter.ProjectID = grant.ProjectID
+	}
+
+	page := &projectPage{ProjectStream: stream, orderBy: query.OrderBy, size: query.Limit - 1}
+	if err := s.store.ListProjects(ctx, page, query); err != nil {
+		return "", statusError(ctx, err)
 	}
-	return nil
+	return s.pager.next(list, page.more, page.last, page.lastValue), nil
 }
+
+// Local errors
+var (
+	errProjectNameMismatch = func(id string) error {
+		return &models.Error{
+			Kind:     models.KindNotFound,
+			Resource: "project",
+			ID:       id,
+			Message:  "Project with this ID has another Name",
+		}
+	}
+)
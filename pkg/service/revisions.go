@@ -0,0 +1,14 @@
+package service
+
+import "github.com/droplez/droplez-studio/pkg/models"
+
+// RevisionMismatchReason is the reason of the ErrorInfo detail sent with an
+// update at a stale revision, its "revision" metadata is the current one
+const RevisionMismatchReason = "REVISION_MISMATCH"
+
+// Local errors
+var (
+	errRevisionRequired = func(resource, id string) error {
+		return models.PreconditionError(resource, id, "the revision that's updated is required, send the etag of the last read as if-match")
+	}
+)
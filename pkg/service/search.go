@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// maxSearchLength bounds the text of a search
+const maxSearchLength = 256
+
+// SearchStore searches projects and versions. Failures are *models.Error
+// values, any other error is a fault of the store.
+type SearchStore interface {
+	// Search sends a page of the hits of the query, best matches first, then
+	// sorted by kind and id
+	Search(context.Context, models.SearchStream, *models.SearchQuery) error
+}
+
+// searchPage sends the hits of a page, the one hit the store sends past
+// the page isn't sent
+type searchPage struct {
+	models.SearchStream
+	size int
+	sent int
+	last string
+	more bool
+}
+
+func (p *searchPage) Send(hit *models.SearchHit) error {
+	if p.sent == p.size {
+		p.more = true
+		return nil
+	}
+	p.sent++
+	p.last = hit.ID
+	return p.SearchStream.Send(hit)
+}
+
+// SearchService searches the names, genres and descriptions of projects and
+// the messages of versions
+type SearchService struct {
+	store SearchStore
+	pager *Pager
+}
+
+func NewSearchService(store SearchStore, pager *Pager) *SearchService {
+	return &SearchService{store: store, pager: pager}
+}
+
+// Search sends a page of the hits of a text, the next one starts at the
+// returned cursor. There's no cursor after the last page. Ranks aren't a
+// keyset, so cursors hold the number of hits already sent.
+func (s *SearchService) Search(ctx context.Context, stream models.SearchStream, text string, params ListParams) (string, error) {
+	var v violations
+	v.text("query", text, true, maxSearchLength)
+	if params.Filter != "" {
+		v.add("filter", "search hits can't be filtered")
+	}
+	if params.OrderBy != "" {
+		v.add("order_by", "search hits are sorted by rank")
+	}
+	list := "search?" + text
+	query := &models.SearchQuery{Text: text}
+	query.ListQuery = *s.pager.query(&v, list, nil, params)
+	if query.After != "" {
+		offset, ok := query.AfterValue.(float64)
+		if !ok || offset < 0 {
+			v.add("cursor", "is not a cursor of this list")
+		}
+		query.Offset = int(offset)
+	}
+	if err := v.err(); err != nil {
+		return "", err
+	}
+
+	// A share link only ever finds what it grants
+	if grant := auth.GrantFromContext(ctx); grant != nil {
+		query.ProjectID, query.VersionID = grant.ProjectID, grant.VersionID
+	}
+
+	page := &searchPage{SearchStream: stream, size: query.Limit - 1}
+	if err := s.store.Search(ctx, page, query); err != nil {
+		return "", statusError(ctx, err)
+	}
+	return s.pager.next(list, page.more, page.last, query.Offset+page.sent), nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/droplez/droplez-studio/third_party/storage"
+	"github.com/google/uuid"
+)
+
+// Clock returns the current time
+type Clock func() time.Time
+
+// IDGenerator returns a new unique id
+type IDGenerator func() string
+
+// NewUUID generates random uuids, it's the IDGenerator of the server
+func NewUUID() string {
+	return uuid.New().String()
+}
+
+// Services is the service layer, it's wired up in main.go and served by pkg/server
+type Services struct {
+	Projects    *ProjectService
+	Versions    *VersionService
+	ShareLinks  *ShareLinkService
+	Downloads   *DownloadService
+	Search      *SearchService
+	Tags        *TagService
+	Collections *CollectionService
+	// Blobs keeps version objects
+	Blobs storage.Backend
+	// Checks probe the backends of the services, they're run by the health server
+	Checks map[string]func(context.Context) error
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ShareLinkStore interface {
+	CreateShareLink(ctx context.Context, link *models.ShareLink) error
+	GetShareLink(ctx context.Context, id string) (*models.ShareLink, error)
+	GetShareLinkByToken(ctx context.Context, tokenHash string) (*models.ShareLink, error)
+	RevokeShareLink(ctx context.Context, id string) error
+	RegisterShareLinkDownload(ctx context.Context, id string, now time.Time) error
+}
+
+// ShareLinkService manages the share links of projects and versions
+type ShareLinkService struct {
+	store    ShareLinkStore
+	projects *ProjectService
+	versions *VersionService
+	now      Clock
+	newID    IDGenerator
+}
+
+func NewShareLinkService(store ShareLinkStore, projects *ProjectService, versions *VersionService, now Clock, newID IDGenerator) *ShareLinkService {
+	return &ShareLinkService{store: store, projects: projects, versions: versions, now: now, newID: newID}
+}
+
+// Create shares a project, or one of its versions, and returns
+// the link with its token, which can't be read again afterwards
+func (s *ShareLinkService) Create(ctx context.Context, in *models.ShareLink) (*models.ShareLink, error) {
+	if err := auth.AuthorizeWrite(ctx); err != nil {
+		return nil, err
+	}
+	var v violations
+	v.shareLink(in)
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(s.now()) {
+		v.add("expires_at", "must be in the future")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	// Make sure the shared resource exists
+	if _, err := s.projects.Get(ctx, &projects.ProjectId{Id: in.ProjectID}); err != nil {
+		return nil, err
+	}
+	if in.VersionID != "" {
+		version, err := s.versions.Get(ctx, &versions.VersionId{Id: in.VersionID})
+		if err != nil {
+			return nil, err
+		}
+		if version.GetMetadata().GetProjectId() != in.ProjectID {
+			v.add("version_id", "doesn't belong to the shared project")
+			return nil, v.err()
+		}
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	out := &models.ShareLink{
+		ID:           s.newID(),
+		ProjectID:    in.ProjectID,
+		VersionID:    in.VersionID,
+		Token:        token,
+		TokenHash:    hashShareToken(token),
+		ExpiresAt:    in.ExpiresAt,
+		MaxDownloads: in.MaxDownloads,
+		CreatedAt:    s.now().UTC(),
+	}
+	if in.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, statusError(ctx, err)
+		}
+		out.PasswordHash = string(hash)
+	}
+
+	if err := s.store.CreateShareLink(ctx, out); err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	return out, nil
+}
+
+// Revoke disables a share link for good
+func (s *ShareLinkService) Revoke(ctx context.Context, id string) error {
+	if err := auth.AuthorizeWrite(ctx); err != nil {
+		return err
+	}
+	var v violations
+	v.id("id", id)
+	if err := v.err(); err != nil {
+		return err
+	}
+
+	if err := s.store.RevokeShareLink(ctx, id); err != nil {
+		return statusError(ctx, err)
+	}
+	return nil
+}
+
+// Check makes sure a share link is neither revoked nor expired, unlike
+// RegisterDownload it doesn't care about the download limit, so the
+// downloads that were already counted can resume
+func (s *ShareLinkService) Check(ctx context.Context, id string) error {
+	link, err := s.store.GetShareLink(ctx, id)
+	if err != nil {
+		return statusError(ctx, err)
+	}
+	if link.Expired(s.now()) {
+		return statusError(ctx, errShareLinkExpired(id))
+	}
+	return nil
+}
+
+// CheckDownloads makes sure a share link can still start a download, it's
+// Check with the download limit
+func (s *ShareLinkService) CheckDownloads(ctx context.Context, id string) error {
+	link, err := s.store.GetShareLink(ctx, id)
+	if err != nil {
+		return statusError(ctx, err)
+	}
+	if link.Expired(s.now()) {
+		return statusError(ctx, errShareLinkExpired(id))
+	}
+	if link.Exhausted() {
+		return statusError(ctx, errShareLinkExhausted(id))
+	}
+	return nil
+}
+
+// RegisterDownload counts a download made through a share link
+func (s *ShareLinkService) RegisterDownload(ctx context.Context, id string) error {
+	if err := s.store.RegisterShareLinkDownload(ctx, id, s.now().UTC()); err != nil {
+		return statusError(ctx, err)
+	}
+	return nil
+}
+
+// Resolve checks a share token and its password, it's used by the
+// authorization layer to build the grant of the caller. A link out of
+// downloads still grants reads, the limit is checked when downloading.
+func (s *ShareLinkService) Resolve(ctx context.Context, token, password string) (*auth.Grant, error) {
+	link, err := s.store.GetShareLinkByToken(ctx, hashShareToken(token))
+	if err != nil {
+		if models.KindOf(err) == models.KindNotFound {
+			return nil, statusError(ctx, errShareLinkInvalid)
+		}
+		return nil, statusError(ctx, err)
+	}
+	if link.Expired(s.now().UTC()) {
+		return nil, statusError(ctx, errShareLinkInvalid)
+	}
+	if link.PasswordHash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+			return nil, statusError(ctx, errShareLinkPassword(link.ID))
+		}
+	}
+
+	return &auth.Grant{
+		LinkID:    link.ID,
+		ProjectID: link.ProjectID,
+		VersionID: link.VersionID,
+	}, nil
+}
+
+func newShareToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Local errors
+var (
+	errShareLinkInvalid = &models.Error{
+		Kind:     models.KindUnauthenticated,
+		Resource: "share link",
+		Message:  "share link is invalid or expired",
+	}
+	errShareLinkPassword = func(id string) error {
+		return models.UnauthenticatedError("share link", id, "share link password is wrong")
+	}
+	errShareLinkExpired = func(id string) error {
+		return models.PermissionError("share link", id, "share link is revoked or expired")
+	}
+	errShareLinkExhausted = func(id string) error {
+		return models.PermissionError("share link", id, "share link is out of downloads")
+	}
+)
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
+)
+
+// Limits of tags, they can't hold commas since filters are comma separated
+const (
+	maxTagLength = 64
+	maxTags      = 50
+)
+
+// TagStore keeps the tags of projects, a project's tags go with it when
+// it's deleted
+type TagStore interface {
+	// SetProjectTags replaces the tags of a project
+	SetProjectTags(ctx context.Context, projectID string, tags []string) error
+	// GetProjectTags returns the tags of a project, sorted
+	GetProjectTags(ctx context.Context, projectID string) ([]string, error)
+	// ListTags returns every tag in use, sorted, with its number of projects
+	ListTags(ctx context.Context) ([]*models.TagCount, error)
+}
+
+// TagService manages the tags of projects
+type TagService struct {
+	store    TagStore
+	projects *ProjectService
+}
+
+func NewTagService(store TagStore, projects *ProjectService) *TagService {
+	return &TagService{store: store, projects: projects}
+}
+
+// Set replaces the tags of a project, they're returned lower case and sorted
+func (s *TagService) Set(ctx context.Context, in *models.ProjectTags) (*models.ProjectTags, error) {
+	if err := auth.AuthorizeWrite(ctx); err != nil {
+		return nil, err
+	}
+	var v violations
+	v.id("project_id", in.ProjectID)
+	tags := v.tags("tags", in.Tags)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	// Make sure the project exists
+	if _, err := s.projects.Get(ctx, &projects.ProjectId{Id: in.ProjectID}); err != nil {
+		return nil, err
+	}
+	if err := s.store.SetProjectTags(ctx, in.ProjectID, tags); err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &models.ProjectTags{ProjectID: in.ProjectID, Tags: tags}, nil
+}
+
+// Get returns the tags of a project
+func (s *TagService) Get(ctx context.Context, projectID string) (*models.ProjectTags, error) {
+	// Get checks the id and the share link
+	if _, err := s.projects.Get(ctx, &projects.ProjectId{Id: projectID}); err != nil {
+		return nil, err
+	}
+	tags, err := s.store.GetProjectTags(ctx, projectID)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &models.ProjectTags{ProjectID: projectID, Tags: tags}, nil
+}
+
+// List returns every tag in use, it's not shared by share links
+func (s *TagService) List(ctx context.Context) ([]*models.TagCount, error) {
+	if err := auth.AuthorizeAll(ctx); err != nil {
+		return nil, err
+	}
+	tags, err := s.store.ListTags(ctx)
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return tags, nil
+}
+
+// tags checks tags and returns them lower case, sorted and without duplicates
+func (v *violations) tags(field string, tags []string) []string {
+	if len(tags) > maxTags {
+		v.add(field, "must be at most %d tags", maxTags)
+	}
+	seen := map[string]bool{}
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !v.tag(field, tag) || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// tag checks a tag, it reports whether it's valid
+func (v *violations) tag(field, tag string) bool {
+	before := len(*v)
+	v.text(field, tag, true, maxTagLength)
+	if strings.Contains(tag, ",") {
+		v.add(field, "tag %q can't contain commas", tag)
+	}
+	return len(*v) == before
+}
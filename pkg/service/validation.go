@@ -0,0 +1,155 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/droplez/droplez-go-proto/pkg/common"
+	"github.com/droplez/droplez-go-proto/pkg/studio/projects"
+	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
+	"github.com/droplez/droplez-studio/pkg/models"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Limits of the stored fields
+const (
+	maxNameLength  = 255
+	maxGenreLength = 64
+	maxTextLength  = 4096
+	minBpm         = 20
+	maxBpm         = 999
+	// bcrypt only hashes the first 72 bytes of a password
+	maxPasswordBytes = 72
+)
+
+// violations collects what's wrong with a request, field by field. Fields are
+// named by their path in the request, like "metadata.name".
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field, format string, args ...interface{}) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// err is the codes.InvalidArgument error of the violations, with a
+// BadRequest detail, or nil when there are none
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	descriptions := make([]string, 0, len(v))
+	for _, violation := range v {
+		descriptions = append(descriptions, violation.GetField()+": "+violation.GetDescription())
+	}
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(descriptions, ", "))
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func (v *violations) id(field, id string) {
+	if id == "" {
+		v.add(field, "is required")
+		return
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		v.add(field, "must be a uuid")
+	}
+}
+
+func (v *violations) text(field, value string, required bool, max int) {
+	if required && strings.TrimSpace(value) == "" {
+		v.add(field, "is required")
+	}
+	if utf8.RuneCountInString(value) > max {
+		v.add(field, "must be at most %d characters", max)
+	}
+}
+
+func (v *violations) paging(field string, paging *common.Paging) {
+	if paging.GetCount() < 0 {
+		v.add(field+".count", "can't be negative")
+	}
+	if paging.GetPage() < 0 {
+		v.add(field+".page", "can't be negative")
+	}
+}
+
+// projectMeta checks the given fields of a project, they're prefixed with
+// the path of the metadata in the request
+func (v *violations) projectMeta(prefix string, meta *projects.ProjectMeta, fields []string) {
+	for _, field := range fields {
+		path := prefix + field
+		switch field {
+		case "name":
+			v.text(path, meta.GetName(), true, maxNameLength)
+		case "description":
+			v.text(path, meta.GetDescription(), false, maxTextLength)
+		case "bpm":
+			if bpm := meta.GetBpm(); bpm != 0 && (bpm < minBpm || bpm > maxBpm) {
+				v.add(path, "must be between %d and %d, or 0 when it's unknown", minBpm, maxBpm)
+			}
+		case "key":
+			if _, ok := models.ParseKey(meta.GetKey()); !ok && meta.GetKey() != "" {
+				v.add(path, "must be a key like C, F#, Bbm, 8A or 1m")
+			}
+		case "genre":
+			v.text(path, meta.GetGenre(), false, maxGenreLength)
+		case "daw":
+			if _, ok := projects.DAW_name[int32(meta.GetDaw())]; !ok {
+				v.add(path, "unknown daw %d", meta.GetDaw())
+			}
+		}
+	}
+}
+
+// normalizeKey writes a valid key of a project in its canonical notation,
+// so projects in the same key are stored alike
+func normalizeKey(meta *projects.ProjectMeta) {
+	if key, ok := models.ParseKey(meta.GetKey()); ok {
+		meta.Key = key.String()
+	}
+}
+
+// versionMeta checks the given fields of a version, they're prefixed with
+// the path of the metadata in the request
+func (v *violations) versionMeta(prefix string, meta *versions.VersionMeta, fields []string) {
+	for _, field := range fields {
+		path := prefix + field
+		switch field {
+		case "version":
+			if meta.GetVersion() < 1 {
+				v.add(path, "must be positive")
+			}
+		case "project_id":
+			v.id(path, meta.GetProjectId())
+		case "object_name":
+			v.text(path, meta.GetObjectName(), true, maxTextLength)
+		case "message":
+			v.text(path, meta.GetMessage(), false, maxTextLength)
+		}
+	}
+}
+
+// shareLink checks a share link to create, the expiry is checked against
+// the clock by the service
+func (v *violations) shareLink(in *models.ShareLink) {
+	v.id("project_id", in.ProjectID)
+	if in.VersionID != "" {
+		v.id("version_id", in.VersionID)
+	}
+	if in.MaxDownloads < 0 {
+		v.add("max_downloads", "can't be negative")
+	}
+	if len(in.Password) > maxPasswordBytes {
+		v.add("password", "must be at most %d bytes", maxPasswordBytes)
+	}
+}
@@ -2,84 +2,209 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/droplez/droplez-go-proto/pkg/common"
 	"github.com/droplez/droplez-go-proto/pkg/studio/versions"
-	"github.com/droplez/droplez-studio/pkg/repo"
-	"github.com/droplez/droplez-studio/third_party/postgres"
-	"github.com/google/uuid"
+	"github.com/droplez/droplez-studio/pkg/auth"
+	"github.com/droplez/droplez-studio/pkg/models"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// VersionStore keeps versions, they're created at revision 1. Failures are
+// *models.Error values, any other error is a fault of the store.
 type VersionStore interface {
-	CreateVersion(ctx context.Context, in *versions.VersionInfo) (codes.Code, error)
-	UpdateVersion(ctx context.Context, in *versions.VersionInfo) (codes.Code, error)
-	GetVersions(ctx context.Context, in *versions.VersionId) (*versions.VersionInfo, codes.Code, error)
-	ListVersions(ctx context.Context, stream versions.Versions_ListServer, options *versions.ListOptions) (codes.Code, error)
+	CreateVersion(ctx context.Context, in *versions.VersionInfo) error
+	// UpdateVersion writes the given metadata fields of a version if it's still
+	// at the given revision and returns the new one, it fails with a
+	// models.KindStale error holding the current one otherwise
+	UpdateVersion(ctx context.Context, in *versions.VersionInfo, revision int64, fields []string) (int64, error)
+	GetVersions(ctx context.Context, in *versions.VersionId) (*models.Version, error)
+	// ListVersions sends a page of filtered versions sorted by id
+	ListVersions(ctx context.Context, stream models.VersionStream, query *models.VersionQuery) error
 }
 
-var versionStore VersionStore
+// versionPage sends the versions of a page, and keeps the last one for the
+// cursor. The one version the store lists past the page isn't sent.
+type versionPage struct {
+	models.VersionStream
+	size int
+	sent int
+	last string
+	more bool
+}
 
-var initVersionsRepo = func(ctx context.Context) VersionStore {
-	if versionStore == nil {
-		versionStore = repo.VersionRepo{
-			Pool: postgres.Pool(ctx),
-		}
+func (p *versionPage) Send(version *models.Version) error {
+	if p.sent == p.size {
+		p.more = true
+		return nil
 	}
-	return versionStore
+	p.sent++
+	p.last = version.GetId().GetId()
+	return p.VersionStream.Send(version)
 }
 
-func VersionCreate(ctx context.Context, in *versions.VersionMeta) (*versions.VersionInfo, error) {
-	repo := initVersionsRepo(ctx)
+// VersionService manages the versions of projects
+type VersionService struct {
+	store       VersionStore
+	projects    ProjectStore
+	idempotency *Idempotency
+	pager       *Pager
+	now         Clock
+	newID       IDGenerator
+}
+
+func NewVersionService(store VersionStore, projects ProjectStore, idempotency *Idempotency, pager *Pager, now Clock, newID IDGenerator) *VersionService {
+	return &VersionService{store: store, projects: projects, idempotency: idempotency, pager: pager, now: now, newID: newID}
+}
 
-	out := &versions.VersionInfo{
-		Id: &versions.VersionId{
-			Id: uuid.New().String(),
-		},
-		Metadata: in,
+// Create a new version, a retry with the same idempotency key gets the
+// version created by the first call
+func (s *VersionService) Create(ctx context.Context, in *versions.VersionMeta, idempotencyKey string) (*models.Version, error) {
+	var v violations
+	v.versionMeta("", in, models.VersionFields)
+	if err := v.err(); err != nil {
+		return nil, err
 	}
-	out.Metadata.UploadedAt = timestamppb.Now()
-	code, err := repo.CreateVersion(ctx, out)
+
+	// Versions have no foreign key, the project is checked here
+	missing, err := s.projects.MissingProjects(ctx, []string{in.GetProjectId()})
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(ctx, err)
+	}
+	if len(missing) > 0 {
+		return nil, statusError(ctx, errVersionProjectNotFound(in.GetProjectId()))
 	}
 
-	return out, nil
+	out := &versions.VersionInfo{}
+	revision, _, err := s.idempotency.run(ctx, "versions.Create", idempotencyKey, in, out, func(now time.Time) (int64, error) {
+		out.Id = &versions.VersionId{
+			Id: s.newID(),
+		}
+		out.Metadata = in
+		// The upload time is set here
+		out.Metadata.UploadedAt = timestamppb.New(now)
+		if err := s.store.CreateVersion(ctx, out); err != nil {
+			return 0, statusError(ctx, err)
+		}
+		return 1, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Version{VersionInfo: out, Revision: revision}, nil
 }
 
-func VersionUpdate(ctx context.Context, in *versions.VersionInfo) (*versions.VersionInfo, error) {
-	repo := initVersionsRepo(ctx)
+// versionMutableFields can be written by an update, a version can't be
+// moved to another project and its upload time follows its object
+var versionMutableFields = []string{"version", "object_name", "message"}
+
+// Update a version, it must still be at the revision the caller read. Only
+// the fields of the mask are written when there's one. The upload time is
+// only set when the object changes.
+func (s *VersionService) Update(ctx context.Context, in *versions.VersionInfo, revision int64, mask *fieldmaskpb.FieldMask) (*models.Version, error) {
+	if revision <= 0 {
+		return nil, statusError(ctx, errRevisionRequired("version", in.GetId().GetId()))
+	}
+	fields := versionMutableFields
+	if mask != nil {
+		var err error
+		if fields, err = maskedFields(mask, versionMutableFields); err != nil {
+			return nil, err
+		}
+	}
+
+	var v violations
+	v.id("id.id", in.GetId().GetId())
+	v.versionMeta("metadata.", in.GetMetadata(), fields)
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	current, err := s.store.GetVersions(ctx, in.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	// A full update carries the project, it must be the current one
+	if projectID := in.GetMetadata().GetProjectId(); mask == nil && projectID != "" && projectID != current.GetMetadata().GetProjectId() {
+		v.add("metadata.project_id", "a version can't be moved to another project")
+		return nil, v.err()
+	}
+
 	out := in
-	out.Metadata.UploadedAt = timestamppb.Now()
-	code, err := repo.UpdateVersion(ctx, out)
+	if out.Metadata == nil {
+		out.Metadata = &versions.VersionMeta{}
+	}
+	if contains(fields, "object_name") && out.Metadata.GetObjectName() != current.GetMetadata().GetObjectName() {
+		// A new object is a new upload
+		fields = append(fields[:len(fields):len(fields)], "uploaded_at")
+		out.Metadata.UploadedAt = timestamppb.New(s.now())
+	}
+	if _, err := s.store.UpdateVersion(ctx, out, revision, fields); err != nil {
+		return nil, statusError(ctx, err)
+	}
+
+	// The version is read back, with the fields that weren't written
+	version, err := s.store.GetVersions(ctx, in.GetId())
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(ctx, err)
 	}
-	return out, nil
+	return version, nil
 }
 
-func VersionDelete(ctx context.Context, in *versions.VersionInfo) (*common.EmptyMessage, error) {
+func (s *VersionService) Delete(ctx context.Context, in *versions.VersionInfo) (*common.EmptyMessage, error) {
 	return nil, status.Error(codes.Unimplemented, "delete versions is not allowed yet")
 }
 
-func VersionGet(ctx context.Context, in *versions.VersionId) (*versions.VersionInfo, error) {
-	repo := initVersionsRepo(ctx)
-
-	out, code, err := repo.GetVersions(ctx, in)
+func (s *VersionService) Get(ctx context.Context, in *versions.VersionId) (*models.Version, error) {
+	var v violations
+	v.id("id", in.GetId())
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	out, err := s.store.GetVersions(ctx, in)
 	if err != nil {
-		return nil, status.Error(code, err.Error())
+		return nil, statusError(ctx, err)
+	}
+	if err := auth.AuthorizeVersion(ctx, out.GetMetadata().GetProjectId(), out.GetId().GetId()); err != nil {
+		return nil, err
 	}
 	return out, nil
 }
 
-func VersionsList(ctx context.Context, stream versions.Versions_ListServer, options *versions.ListOptions) error {
-	repo := initVersionsRepo(ctx)
-	code, err := repo.ListVersions(ctx, stream, options)
-	if err != nil {
-		return status.Error(code, err.Error())
+// List sends a page of versions, the next one starts at the returned
+// cursor. There's no cursor after the last page.
+func (s *VersionService) List(ctx context.Context, stream models.VersionStream, options *versions.ListOptions, params ListParams) (string, error) {
+	var v violations
+	if params.Filter != "" {
+		v.add("filter", "versions can't be filtered")
+	}
+	if params.OrderBy != "" {
+		v.add("order_by", "versions can't be sorted")
+	}
+	query := &models.VersionQuery{ListQuery: *s.pager.query(&v, "versions", options.GetPaging(), params)}
+	if err := v.err(); err != nil {
+		return "", err
 	}
-	return nil
 
+	// A share link only ever lists versions it grants
+	if grant := auth.GrantFromContext(ctx); grant != nil {
+		query.Filter.ProjectID, query.Filter.VersionID = grant.ProjectID, grant.VersionID
+	}
+	page := &versionPage{VersionStream: stream, size: query.Limit - 1}
+	if err := s.store.ListVersions(ctx, page, query); err != nil {
+		return "", statusError(ctx, err)
+	}
+	return s.pager.next("versions", page.more, page.last, nil), nil
 }
+
+// Local errors
+var (
+	errVersionProjectNotFound = func(projectID string) error {
+		return models.NotFoundError("project", projectID)
+	}
+)
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"github.com/droplez/droplez-studio/tools/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	acquireCountDesc = poolDesc("acquire_total", "Connections acquired from the pool.")
+	acquireSecsDesc  = poolDesc("acquire_seconds_total", "Time spent acquiring connections from the pool.")
+	canceledDesc     = poolDesc("acquire_canceled_total", "Acquires cancelled by their context.")
+	emptyAcquireDesc = poolDesc("acquire_empty_total", "Acquires that had to wait for a connection.")
+	acquiredDesc     = poolDesc("acquired_connections", "Connections currently in use.")
+	constructingDesc = poolDesc("constructing_connections", "Connections being opened.")
+	idleDesc         = poolDesc("idle_connections", "Idle connections.")
+	totalDesc        = poolDesc("connections", "Open connections.")
+	maxDesc          = poolDesc("max_connections", "Maximum size of the pool.")
+)
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "database_pool", name), help, nil, nil)
+}
+
+// Collector exports the statistics of the connection pool
+type Collector struct{}
+
+func (Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{acquireCountDesc, acquireSecsDesc, canceledDesc, emptyAcquireDesc, acquiredDesc, constructingDesc, idleDesc, totalDesc, maxDesc} {
+		ch <- desc
+	}
+}
+
+func (Collector) Collect(ch chan<- prometheus.Metric) {
+	mu.Lock()
+	p := pool
+	mu.Unlock()
+	// Nothing to report until the pool is opened
+	if p == nil {
+		return
+	}
+	stat := p.Stat()
+	ch <- prometheus.MustNewConstMetric(acquireCountDesc, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(acquireSecsDesc, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(canceledDesc, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(emptyAcquireDesc, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(acquiredDesc, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(constructingDesc, prometheus.GaugeValue, float64(stat.ConstructingConns()))
+	ch <- prometheus.MustNewConstMetric(idleDesc, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(totalDesc, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(maxDesc, prometheus.GaugeValue, float64(stat.MaxConns()))
+}
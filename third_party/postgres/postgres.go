@@ -3,11 +3,13 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/droplez/droplez-studio/tools/logger"
 
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/spf13/viper"
 )
@@ -32,47 +34,116 @@ func NewConnectionParams() *ConnectionParams {
 }
 
 var (
-	pool       *pgxpool.Pool
-	maxRetries = 5
+	pool *pgxpool.Pool
+	mu   sync.Mutex
 )
 
 var errCantConnect = "unable to connect to database"
 
-func Pool(ctx context.Context) *pgxpool.Conn {
+// Open creates the connection pool and waits until the database answers,
+// it's called on startup
+func Open(ctx context.Context) error {
 	log := logger.GetServerLogger()
-	if pool == nil {
-		err := openConnectionPool(ctx)
-		if err != nil {
+	p, err := open()
+	if err != nil {
+		return err
+	}
+
+	retries := viper.GetInt("database_connect_retries")
+	for i := 0; ; i++ {
+		err = p.Ping(ctx)
+		if err == nil {
 			return nil
 		}
-	}
-	conn, err := pool.Acquire(ctx)
-
-	if err == pgx.ErrDeadConn {
-		if maxRetries > 0 {
-			log.Infof("%v, try acquiring a non-dead connection", err)
-			pool.Close()
-			openConnectionPool(ctx)
-			return Pool(ctx)
+		log.Error(err)
+		if i >= retries {
+			log.Error(errCantConnect)
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(viper.GetDuration("database_connect_retry_delay")):
 		}
 	}
-	return conn
 }
 
-func openConnectionPool(ctx context.Context) error {
-	var err error
-	log := logger.GetServerLogger()
+// Pool returns the connection pool, creating it on first use. Connections are
+// acquired for every statement and given back right after, so the pool is
+// shared by all the repos
+func Pool() *pgxpool.Pool {
+	p, err := open()
+	if err != nil {
+		return nil
+	}
+	return p
+}
+
+func open() (*pgxpool.Pool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool != nil {
+		return pool, nil
+	}
+	config, err := poolConfig()
+	if err != nil {
+		logger.GetServerLogger().Error(err)
+		return nil, err
+	}
+	// Connections are opened when they're needed, so the pool can be
+	// created while the database is down
+	pool, err = pgxpool.ConnectConfig(context.Background(), config)
+	if err != nil {
+		logger.GetServerLogger().Error(err)
+		return nil, err
+	}
+	return pool, nil
+}
+
+func poolConfig() (*pgxpool.Config, error) {
 	params := NewConnectionParams()
 	connectionString := fmt.Sprintf("postgres://%s:%s@%s:%s/%s", params.Username, params.Password, params.Host, params.Port, params.Database)
-	for i := 0; i < maxRetries; i++ {
-		pool, err = pgxpool.Connect(ctx, connectionString)
-		if err != nil {
-			log.Error(err)
-			time.Sleep(5 * time.Second)
-			continue
-		}
-		return nil
+	config, err := pgxpool.ParseConfig(connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	config.LazyConnect = true
+	if maxConns := viper.GetInt32("database_max_conns"); maxConns > 0 {
+		config.MaxConns = maxConns
+	}
+	config.MinConns = viper.GetInt32("database_min_conns")
+	config.MaxConnLifetime = viper.GetDuration("database_max_conn_lifetime")
+	config.MaxConnIdleTime = viper.GetDuration("database_max_conn_idle_time")
+	config.HealthCheckPeriod = viper.GetDuration("database_health_check_period")
+	config.ConnConfig.ConnectTimeout = viper.GetDuration("database_connect_timeout")
+	if timeout := viper.GetDuration("database_statement_timeout"); timeout > 0 {
+		config.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+
+	// Dead connections are dropped by the health check and when they are
+	// released, the ones that died while idle are skipped here
+	config.BeforeAcquire = func(ctx context.Context, conn *pgx.Conn) bool {
+		return !conn.IsClosed()
+	}
+	return config, nil
+}
+
+// Close closes the pool, it's called on shutdown
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if pool != nil {
+		pool.Close()
+		pool = nil
+	}
+}
+
+// Ping checks that the database answers
+func Ping(ctx context.Context) error {
+	p, err := open()
+	if err != nil {
+		return err
 	}
-	log.Error(errCantConnect)
-	return err
+	return p.Ping(ctx)
 }
@@ -0,0 +1,95 @@
+// Package sqlite opens the database file of the sqlite storage, it's meant
+// for a single user running the server next to their DAW
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/spf13/viper"
+
+	// database/sql driver, it's pure go so the server still builds without cgo
+	_ "modernc.org/sqlite"
+)
+
+var (
+	db *sql.DB
+	mu sync.Mutex
+)
+
+// Path of the database file
+func Path() string {
+	return viper.GetString("sqlite_path")
+}
+
+// Open opens the database file, creating it and its directory when they
+// don't exist, it's called on startup
+func Open(ctx context.Context) error {
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	if err := d.PingContext(ctx); err != nil {
+		logger.GetServerLogger().Error(err)
+		return err
+	}
+	return nil
+}
+
+// DB returns the database, opening it on first use
+func DB() *sql.DB {
+	d, err := open()
+	if err != nil {
+		return nil
+	}
+	return d
+}
+
+func open() (*sql.DB, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if db != nil {
+		return db, nil
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		logger.GetServerLogger().Error(err)
+		return nil, err
+	}
+	d, err := sql.Open("sqlite", path)
+	if err != nil {
+		logger.GetServerLogger().Error(err)
+		return nil, err
+	}
+	// SQLite takes one writer at a time, a single connection queues the
+	// statements instead of failing them with SQLITE_BUSY. The repos read
+	// whole pages before sending them, so no call holds it for a client.
+	d.SetMaxOpenConns(1)
+	db = d
+	return db, nil
+}
+
+// Close closes the database, it's called on shutdown
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if db != nil {
+		if err := db.Close(); err != nil {
+			logger.GetServerLogger().Error(err)
+		}
+		db = nil
+	}
+}
+
+// Ping checks that the database file can be read
+func Ping(ctx context.Context) error {
+	d, err := open()
+	if err != nil {
+		return err
+	}
+	return d.PingContext(ctx)
+}
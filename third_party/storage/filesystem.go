@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Filesystem keeps objects as files in a local directory
+type Filesystem struct {
+	Root string
+}
+
+func NewFilesystem(root string) *Filesystem {
+	return &Filesystem{Root: root}
+}
+
+func (f *Filesystem) Name() string {
+	return "filesystem"
+}
+
+func (f *Filesystem) Open(ctx context.Context, name string) (Object, error) {
+	filename, err := f.path(name)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, ErrNotFound
+	}
+	return &fileObject{File: file, info: info}, nil
+}
+
+func (f *Filesystem) Put(ctx context.Context, name string, content io.Reader) error {
+	filename, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+		return err
+	}
+
+	// Write next to the object and rename, so readers never see half of it
+	file, err := ioutil.TempFile(filepath.Dir(filename), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filename)
+}
+
+func (f *Filesystem) Delete(ctx context.Context, name string) error {
+	filename, err := f.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filename); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Usage walks the root, probes aren't counted
+func (f *Filesystem) Usage(ctx context.Context) (objects int64, size int64, err error) {
+	err = filepath.Walk(f.Root, func(filename string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if info.IsDir() {
+			if filename != f.Root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		objects++
+		size += info.Size()
+		return nil
+	})
+	return objects, size, err
+}
+
+// path maps an object name into the root, names can't leave it
+func (f *Filesystem) path(name string) (string, error) {
+	clean, err := cleanName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(f.Root, filepath.FromSlash(clean)), nil
+}
+
+type fileObject struct {
+	*os.File
+	info os.FileInfo
+}
+
+func (o *fileObject) Size() int64 {
+	return o.info.Size()
+}
+
+func (o *fileObject) ModTime() time.Time {
+	return o.info.ModTime()
+}
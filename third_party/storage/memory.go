@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory keeps objects in memory, for demos and tests
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	content []byte
+	modTime time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{objects: map[string]memoryObject{}}
+}
+
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+func (m *Memory) Open(ctx context.Context, name string) (Object, error) {
+	name, err := cleanName(name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	object, ok := m.objects[name]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	// Contents are never changed in place, so readers can share them
+	return &openedMemoryObject{Reader: bytes.NewReader(object.content), memoryObject: object}, nil
+}
+
+func (m *Memory) Put(ctx context.Context, name string, content io.Reader) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[name] = memoryObject{content: data, modTime: time.Now()}
+	return nil
+}
+
+func (m *Memory) Delete(ctx context.Context, name string) error {
+	name, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.objects, name)
+	return nil
+}
+
+// Usage counts the objects kept, probes aren't counted
+func (m *Memory) Usage(ctx context.Context) (objects int64, size int64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, object := range m.objects {
+		if strings.HasPrefix(name, probePrefix) {
+			continue
+		}
+		objects++
+		size += int64(len(object.content))
+	}
+	return objects, size, nil
+}
+
+type openedMemoryObject struct {
+	*bytes.Reader
+	memoryObject
+}
+
+func (o *openedMemoryObject) Close() error {
+	return nil
+}
+
+func (o *openedMemoryObject) Size() int64 {
+	return int64(len(o.content))
+}
+
+func (o *openedMemoryObject) ModTime() time.Time {
+	return o.modTime
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/droplez/droplez-studio/tools/logger"
+	"github.com/droplez/droplez-studio/tools/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Usage is reported by backends that know how much they keep
+type Usage interface {
+	// Usage returns the number of objects and their total size in bytes
+	Usage(ctx context.Context) (objects int64, size int64, err error)
+}
+
+// backendUsage returns the usage of a backend. Wrappers embed Backend, which
+// doesn't have Usage, so they forward it with this to keep the usage of the
+// backend they wrap visible to the Collector.
+func backendUsage(ctx context.Context, backend Backend) (int64, int64, error) {
+	usage, ok := backend.(Usage)
+	if !ok {
+		return 0, 0, errUsageUnknown
+	}
+	return usage.Usage(ctx)
+}
+
+// metered counts the bytes going in and out of a backend, probes aren't counted
+type metered struct {
+	Backend
+}
+
+func (m metered) Open(ctx context.Context, name string) (Object, error) {
+	object, err := m.Backend.Open(ctx, name)
+	if err != nil || strings.HasPrefix(name, probePrefix) {
+		return object, err
+	}
+	return &meteredObject{Object: object, counter: metrics.DownloadedBytes.WithLabelValues(m.Name())}, nil
+}
+
+func (m metered) Put(ctx context.Context, name string, content io.Reader) error {
+	if strings.HasPrefix(name, probePrefix) {
+		return m.Backend.Put(ctx, name, content)
+	}
+	return m.Backend.Put(ctx, name, &meteredReader{Reader: content, counter: metrics.UploadedBytes.WithLabelValues(m.Name())})
+}
+
+// Usage isn't metered, it's only read by the Collector
+func (m metered) Usage(ctx context.Context) (int64, int64, error) {
+	return backendUsage(ctx, m.Backend)
+}
+
+type meteredObject struct {
+	Object
+	counter prometheus.Counter
+}
+
+func (o *meteredObject) Read(p []byte) (int, error) {
+	n, err := o.Object.Read(p)
+	o.counter.Add(float64(n))
+	return n, err
+}
+
+type meteredReader struct {
+	io.Reader
+	counter prometheus.Counter
+}
+
+func (r *meteredReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.counter.Add(float64(n))
+	return n, err
+}
+
+var (
+	objectsDesc = prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "storage", "objects"), "Objects kept by the storage backend.", []string{"backend"}, nil)
+	sizeDesc    = prometheus.NewDesc(prometheus.BuildFQName(metrics.Namespace, "storage", "size_bytes"), "Size of the objects kept by the storage backend.", []string{"backend"}, nil)
+)
+
+// Collector exports the usage of storage backends
+type Collector struct {
+	Backends []Backend
+}
+
+func NewCollector(backends ...Backend) *Collector {
+	return &Collector{Backends: backends}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- objectsDesc
+	ch <- sizeDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, backend := range c.Backends {
+		usage, ok := backend.(Usage)
+		if !ok {
+			continue
+		}
+		objects, size, err := usage.Usage(ctx)
+		if err != nil {
+			if err != errUsageUnknown {
+				logger.GetServerLogger().WithField("backend", backend.Name()).Error(err)
+			}
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(objectsDesc, prometheus.GaugeValue, float64(objects), backend.Name())
+		ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(size), backend.Name())
+	}
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Object is an opened version object
+type Object interface {
+	io.ReadSeekCloser
+	Size() int64
+	ModTime() time.Time
+}
+
+// Backend keeps version objects
+type Backend interface {
+	// Name of the backend
+	Name() string
+	// Open an object for reading
+	Open(ctx context.Context, name string) (Object, error)
+	// Put writes an object, replacing it when it exists
+	Put(ctx context.Context, name string, content io.Reader) error
+	// Delete an object
+	Delete(ctx context.Context, name string) error
+}
+
+// ErrNotFound is returned when an object doesn't exist
+var ErrNotFound = errors.New("object can not be found")
+
+// ErrInvalidName is returned when an object name could escape the storage
+var ErrInvalidName = errors.New("object name is invalid")
+
+// cleanName resolves the dots and slashes of an object name, it can't
+// point above the root of the storage
+func cleanName(name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "\x00") {
+		return "", ErrInvalidName
+	}
+	return clean[1:], nil
+}
+
+var errUsageUnknown = errors.New("backend doesn't report its usage")
+
+// probePrefix holds the objects written by Probe
+const probePrefix = ".probe/"
+
+var backend Backend
+
+// Default returns the backend configured for the server
+func Default() Backend {
+	if backend == nil {
+		backend = Instrument(NewFilesystem(viper.GetString("storage_path")))
+	}
+	return backend
+}
+
+// Instrument counts the traffic of a backend in the metrics and traces it
+func Instrument(backend Backend) Backend {
+	return traced{metered{backend}}
+}
+
+// Probe writes, reads back and deletes an object to check that the backend works
+func Probe(ctx context.Context, backend Backend) error {
+	content := make([]byte, 16)
+	if _, err := rand.Read(content); err != nil {
+		return err
+	}
+	name := probePrefix + hex.EncodeToString(content)
+
+	if err := backend.Put(ctx, name, bytes.NewReader(content)); err != nil {
+		return err
+	}
+	defer backend.Delete(ctx, name)
+
+	object, err := backend.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+	read, err := ioutil.ReadAll(object)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(read, content) {
+		return fmt.Errorf("%s backend returned another content than it was given", backend.Name())
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/droplez/droplez-studio/tools/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traced starts a span for every operation on a backend
+type traced struct {
+	Backend
+}
+
+func (t traced) Open(ctx context.Context, name string) (Object, error) {
+	ctx, span := t.start(ctx, "storage.Open", name)
+	object, err := t.Backend.Open(ctx, name)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("storage.object_size", object.Size()))
+	}
+	tracing.End(span, ignoreNotFound(err))
+	return object, err
+}
+
+func (t traced) Put(ctx context.Context, name string, content io.Reader) error {
+	ctx, span := t.start(ctx, "storage.Put", name)
+	err := t.Backend.Put(ctx, name, content)
+	tracing.End(span, err)
+	return err
+}
+
+func (t traced) Delete(ctx context.Context, name string) error {
+	ctx, span := t.start(ctx, "storage.Delete", name)
+	err := t.Backend.Delete(ctx, name)
+	tracing.End(span, ignoreNotFound(err))
+	return err
+}
+
+// Usage isn't traced, the scrapes of the Collector would flood the traces
+func (t traced) Usage(ctx context.Context) (int64, int64, error) {
+	return backendUsage(ctx, t.Backend)
+}
+
+// start a span, probes run every few seconds and aren't traced
+func (t traced) start(ctx context.Context, op, name string) (context.Context, trace.Span) {
+	if strings.HasPrefix(name, probePrefix) {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	return tracing.Start(ctx, op,
+		attribute.String("storage.backend", t.Name()),
+		attribute.String("storage.object", name),
+	)
+}
+
+// A missing object is an answer, not a failure of the backend
+func ignoreNotFound(err error) error {
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
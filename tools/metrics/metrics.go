@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+
+	grpc_prometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the metrics of the server
+const Namespace = "droplez_studio"
+
+var (
+	// Registry holds every metric exported by the server
+	Registry = prometheus.NewRegistry()
+
+	// GrpcMetrics counts calls, their latency and status codes per method
+	GrpcMetrics = grpc_prometheus.NewServerMetrics()
+
+	// UploadedBytes counts the bytes written to a storage backend
+	UploadedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "storage",
+		Name:      "uploaded_bytes_total",
+		Help:      "Bytes written to the storage backend.",
+	}, []string{"backend"})
+
+	// DownloadedBytes counts the bytes read from a storage backend
+	DownloadedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "storage",
+		Name:      "downloaded_bytes_total",
+		Help:      "Bytes read from the storage backend.",
+	}, []string{"backend"})
+)
+
+func init() {
+	GrpcMetrics.EnableHandlingTimeHistogram()
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		GrpcMetrics,
+		UploadedBytes,
+		DownloadedBytes,
+	)
+}
+
+// Handler exports the metrics in the prometheus format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
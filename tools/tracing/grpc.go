@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Tags under which the ids of the span are added to the grpc logs
+const (
+	TraceIDTag = "trace.trace_id"
+	SpanIDTag  = "trace.span_id"
+)
+
+// UnaryServerInterceptor starts a span per call, it must run after the
+// ctxtags interceptor and before the logrus one, so that the logs carry the
+// trace ids
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	traced := otelgrpc.UnaryServerInterceptor()
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return traced(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			tagSpan(ctx)
+			return handler(ctx, req)
+		})
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streams
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	traced := otelgrpc.StreamServerInterceptor()
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return traced(srv, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+			tagSpan(stream.Context())
+			return handler(srv, stream)
+		})
+	}
+}
+
+func tagSpan(ctx context.Context) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return
+	}
+	grpc_ctxtags.Extract(ctx).
+		Set(TraceIDTag, spanContext.TraceID().String()).
+		Set(SpanIDTag, spanContext.SpanID().String())
+}